@@ -0,0 +1,261 @@
+package jackett
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// AdminIndexer is an indexer as listed by the Jackett admin api, which
+// unlike t=indexers also reports the indexer type and its last error.
+type AdminIndexer struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Description          string   `json:"description"`
+	Type                 string   `json:"type"`
+	Configured           bool     `json:"configured"`
+	SiteLink             string   `json:"site_link"`
+	AlternativeSiteLinks []string `json:"alternativesitelinks"`
+	Language             string   `json:"language"`
+	LastError            string   `json:"last_error"`
+	PotatoEnabled        bool     `json:"potatoenabled"`
+
+	Caps []AdminCategory `json:"caps"`
+}
+
+type AdminCategory struct {
+	// quoted or not depending on the Jackett version
+	ID   json.Number `json:"ID"`
+	Name string      `json:"Name"`
+}
+
+func (i AdminIndexer) HasError() bool {
+	return i.LastError != ""
+}
+
+// Private reports whether the indexer is private or semi-private.
+func (i AdminIndexer) Private() bool {
+	return i.Type == "private" || i.Type == "semi-private"
+}
+
+// GetAdminIndexersCtx lists the configured indexers through the Jackett admin
+// api, logging in with Config.AdminPassword when the dashboard is protected.
+func (c *Client) GetAdminIndexersCtx(ctx context.Context) ([]AdminIndexer, error) {
+	var indexers []AdminIndexer
+	if err := c.doAdmin(ctx, http.MethodGet, "", map[string]string{"configured": "true"}, nil, &indexers); err != nil {
+		return nil, errors.Wrap(err, "could not get admin indexers")
+	}
+
+	return indexers, nil
+}
+
+// doAdmin sends an admin api request, encoding body as JSON and decoding
+// the response into v when not nil. It logs in once when redirected to the
+// login page.
+func (c *Client) doAdmin(ctx context.Context, method, endpoint string, opts map[string]string, body, v interface{}) error {
+	return c.doAdminUrl(ctx, method, c.buildUrl(endpoint, opts), body, v)
+}
+
+// doAdminUrl is doAdmin for admin api urls outside of the indexers api.
+func (c *Client) doAdminUrl(ctx context.Context, method, reqUrl string, body, v interface{}) error {
+	var payload []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "could not encode request")
+		}
+
+		payload = b
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, reqUrl, bytes.NewReader(payload))
+		if err != nil {
+			return errors.Wrap(err, "could not build request")
+		}
+
+		if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" {
+			req.SetBasicAuth(c.cfg.BasicUser, c.cfg.BasicPass)
+		}
+
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.retryDo(ctx, req)
+		if err != nil {
+			return errors.Wrap(err, "error making %v request: %v", method, c.redact(reqUrl))
+		}
+
+		if isLoginPage(resp) && attempt == 0 && c.cfg.AdminPassword != "" {
+			drainAndClose(resp.Body)
+
+			if err := c.loginCtx(ctx); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		defer drainAndClose(resp.Body)
+
+		if isLoginPage(resp) {
+			return errors.New("admin api requires login, set AdminPassword")
+		}
+
+		if resp.StatusCode >= 400 {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+			return &StatusError{Status: resp.StatusCode, Body: truncateBody(b)}
+		}
+
+		if v == nil {
+			return nil
+		}
+
+		return decodeBody(resp, v)
+	}
+}
+
+// loginCtx logs into the Jackett dashboard, the session cookie is kept in
+// the cookie jar.
+func (c *Client) loginCtx(ctx context.Context) error {
+	form := url.Values{}
+	form.Set("password", c.cfg.AdminPassword)
+
+	loginUrl, _ := url.JoinPath(c.host(), "/UI/Dashboard")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "could not build request")
+	}
+
+	if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" {
+		req.SetBasicAuth(c.cfg.BasicUser, c.cfg.BasicPass)
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.do(c.http, req)
+	if err != nil {
+		return errors.Wrap(err, "error logging in: %v", loginUrl)
+	}
+
+	drainAndClose(resp.Body)
+
+	if isLoginPage(resp) {
+		return errors.New("could not log in, check AdminPassword")
+	}
+
+	if resp.StatusCode >= 400 {
+		return errors.New("could not log in: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// isLoginPage reports whether the request was redirected to the login page.
+func isLoginPage(resp *http.Response) bool {
+	return resp.Request != nil && strings.Contains(strings.ToLower(resp.Request.URL.Path), "/ui/login")
+}
+
+// IndexerConfig is the configuration form of an indexer as used by the
+// Jackett admin api.
+type IndexerConfig []IndexerConfigItem
+
+type IndexerConfigItem struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+
+	// string, bool or string list depending on the item type
+	Value json.RawMessage `json:"value,omitempty"`
+
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Get returns the item with the given id.
+func (ic IndexerConfig) Get(id string) (IndexerConfigItem, bool) {
+	for _, item := range ic {
+		if item.ID == id {
+			return item, true
+		}
+	}
+
+	return IndexerConfigItem{}, false
+}
+
+// Set sets the value of the item with the given id, e.g. Set("username", "me").
+func (ic IndexerConfig) Set(id string, value interface{}) error {
+	for i := range ic {
+		if ic[i].ID != id {
+			continue
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "could not encode value of %v", id)
+		}
+
+		ic[i].Value = b
+		return nil
+	}
+
+	return errors.New("unknown config item: %v", id)
+}
+
+// StringValue returns the value of a string item.
+func (item IndexerConfigItem) StringValue() string {
+	var s string
+	_ = json.Unmarshal(item.Value, &s)
+	return s
+}
+
+func (c *Client) GetIndexerConfigCtx(ctx context.Context, id string) (IndexerConfig, error) {
+	var cfg IndexerConfig
+	if err := c.doAdmin(ctx, http.MethodGet, id+"/config", nil, nil, &cfg); err != nil {
+		return nil, errors.Wrap(err, "could not get config of indexer %v", id)
+	}
+
+	return cfg, nil
+}
+
+func (c *Client) UpdateIndexerConfigCtx(ctx context.Context, id string, cfg IndexerConfig) error {
+	if err := c.doAdmin(ctx, http.MethodPost, id+"/config", nil, cfg, nil); err != nil {
+		return errors.Wrap(err, "could not update config of indexer %v", id)
+	}
+
+	return nil
+}
+
+// AddIndexerCtx configures an unconfigured indexer, which adds it to Jackett.
+// The config defaults to the one Jackett offers for the indexer, which is
+// enough for public trackers. Private trackers are usually configured by
+// fetching the config with GetIndexerConfigCtx and setting the credentials.
+func (c *Client) AddIndexerCtx(ctx context.Context, id string, cfg IndexerConfig) error {
+	if cfg == nil {
+		var err error
+		if cfg, err = c.GetIndexerConfigCtx(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := c.doAdmin(ctx, http.MethodPost, id+"/config", nil, cfg, nil); err != nil {
+		return errors.Wrap(err, "could not add indexer %v", id)
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteIndexerCtx(ctx context.Context, id string) error {
+	if err := c.doAdmin(ctx, http.MethodDelete, id, nil, nil, nil); err != nil {
+		return errors.Wrap(err, "could not delete indexer %v", id)
+	}
+
+	return nil
+}
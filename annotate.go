@@ -0,0 +1,20 @@
+package jackett
+
+// Annotator adds computed fields to the Meta map of an item, e.g. internal
+// library ids, so pipelines can enrich results without wrapping FeedItem.
+type Annotator func(item *FeedItem)
+
+func (c *Client) annotate(items []FeedItem) {
+	if len(c.cfg.Annotators) == 0 {
+		return
+	}
+
+	for i := range items {
+		// cached items share their map, start every search from a fresh one
+		items[i].Meta = make(map[string]interface{})
+
+		for _, annotate := range c.cfg.Annotators {
+			annotate(&items[i])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package jackett
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Errors the errors of failed requests match with errors.Is, whatever else
+// they wrap. ErrRateLimited is matched by rate limited requests as well.
+var (
+	// the api key or credentials were refused
+	ErrInvalidAPIKey = errors.Sentinel("invalid api key")
+
+	// no api key is configured, see Config.AllowMissingAPIKey
+	ErrMissingAPIKey = errors.Sentinel("missing api key")
+
+	ErrIndexerNotFound = errors.Sentinel("indexer not found")
+
+	// the server kept failing with 5xx statuses
+	ErrServerError = errors.Sentinel("server error")
+
+	// the response could not be decoded
+	ErrParse = errors.Sentinel("could not parse response")
+)
+
+// Torznab error codes, see the newznab api specification.
+const (
+	ErrCodeIncorrectCredentials = 100
+	ErrCodeAccountSuspended     = 101
+	ErrCodeInsufficientRights   = 102
+	ErrCodeMissingParameter     = 200
+	ErrCodeIncorrectParameter   = 201
+	ErrCodeNoSuchFunction       = 202
+	ErrCodeNotAvailable         = 203
+	ErrCodeUnknown              = 900
+)
+
+// TorznabError is an error document returned instead of results, e.g. for
+// an invalid api key.
+type TorznabError struct {
+	Code        int
+	Description string
+
+	// http status of the response
+	Status int
+}
+
+func (e *TorznabError) Error() string {
+	return "torznab error " + strconv.Itoa(e.Code) + ": " + e.Description
+}
+
+// Auth reports whether the error is caused by the credentials or api key.
+func (e *TorznabError) Auth() bool {
+	return e.Code >= ErrCodeIncorrectCredentials && e.Code <= ErrCodeInsufficientRights
+}
+
+func (e *TorznabError) Is(target error) bool {
+	switch target {
+	case ErrInvalidAPIKey:
+		return e.Auth()
+	case ErrIndexerNotFound:
+		return e.Status == 404
+	}
+
+	return false
+}
+
+// StatusError is returned for responses with an error status that carry no
+// torznab error document.
+type StatusError struct {
+	Status int
+
+	// truncated, empty when not read
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return "unexpected status: " + strconv.Itoa(e.Status)
+	}
+
+	return "unexpected status: " + strconv.Itoa(e.Status) + ", body " + strconv.Quote(e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrInvalidAPIKey:
+		return e.Status == 401 || e.Status == 403
+	case ErrIndexerNotFound:
+		return e.Status == 404
+	case ErrRateLimited:
+		return e.Status == 429
+	case ErrServerError:
+		return e.Status >= 500
+	}
+
+	return false
+}
+
+// ParseError is returned when a response can't be decoded, it matches
+// ErrParse.
+type ParseError struct {
+	// xml, json or html, empty when unknown
+	Format string
+
+	Status      int
+	ContentType string
+
+	// truncated
+	Body string
+
+	// the decoder error, nil when the format isn't decodable
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var msg string
+	switch {
+	case e.Format == "html":
+		msg = "unexpected html response, check host and api key"
+	case e.Err != nil:
+		msg = "could not decode " + e.Format + " response"
+	default:
+		msg = "unknown response format"
+	}
+
+	msg += ": status " + strconv.Itoa(e.Status) + ", content-type " + strconv.Quote(e.ContentType) + ", body " + strconv.Quote(e.Body)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ParseError) Is(target error) bool {
+	if target == ErrParse {
+		return true
+	}
+
+	// html pages are what a wrong host or refused key usually gets
+	return target == ErrInvalidAPIKey && e.Format == "html" && (e.Status == 401 || e.Status == 403)
+}
+
+// parseTorznabError returns the error of an <error> document, or nil for any
+// other document. Only the root element is read for other documents.
+func parseTorznabError(body []byte, status int) *TorznabError {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if start.Name.Local != "error" {
+			return nil
+		}
+
+		var doc struct {
+			Code        string `xml:"code,attr"`
+			Description string `xml:"description,attr"`
+		}
+
+		if err := dec.DecodeElement(&doc, &start); err != nil {
+			return nil
+		}
+
+		code, _ := strconv.Atoi(doc.Code)
+		return &TorznabError{Code: code, Description: doc.Description, Status: status}
+	}
+}
@@ -0,0 +1,178 @@
+package jackett
+
+import (
+	"sort"
+	"sync"
+)
+
+// AttrName is the name of a torznab attribute, see GetAttr.
+type AttrName string
+
+// Torznab attribute names.
+const (
+	AttrSize                 AttrName = "size"
+	AttrCategory             AttrName = "category"
+	AttrFiles                AttrName = "files"
+	AttrGrabs                AttrName = "grabs"
+	AttrSeeders              AttrName = "seeders"
+	AttrLeechers             AttrName = "leechers"
+	AttrPeers                AttrName = "peers"
+	AttrInfoHash             AttrName = "infohash"
+	AttrMagnetURL            AttrName = "magneturl"
+	AttrDownloadVolumeFactor AttrName = "downloadvolumefactor"
+	AttrUploadVolumeFactor   AttrName = "uploadvolumefactor"
+	AttrMinimumRatio         AttrName = "minimumratio"
+	AttrMinimumSeedTime      AttrName = "minimumseedtime"
+	AttrInfoURL              AttrName = "infourl"
+
+	AttrIMDB     AttrName = "imdb"
+	AttrIMDBID   AttrName = "imdbid"
+	AttrTMDBID   AttrName = "tmdbid"
+	AttrTVDBID   AttrName = "tvdbid"
+	AttrTVMazeID AttrName = "tvmazeid"
+	AttrRageID   AttrName = "rageid"
+	AttrTVRageID AttrName = "tvrageid"
+	AttrTraktID  AttrName = "traktid"
+	AttrDoubanID AttrName = "doubanid"
+
+	AttrSeason  AttrName = "season"
+	AttrEpisode AttrName = "episode"
+	AttrYear    AttrName = "year"
+	AttrGenre   AttrName = "genre"
+	AttrTeam    AttrName = "team"
+	AttrTag     AttrName = "tag"
+
+	AttrLanguage AttrName = "language"
+	AttrSubs     AttrName = "subs"
+
+	AttrCoverURL  AttrName = "coverurl"
+	AttrBookTitle AttrName = "booktitle"
+	AttrAuthor    AttrName = "author"
+	AttrPublisher AttrName = "publisher"
+	AttrArtist    AttrName = "artist"
+	AttrAlbum     AttrName = "album"
+	AttrLabel     AttrName = "label"
+	AttrTrack     AttrName = "track"
+
+	// newznab attributes of usenet releases
+	AttrPoster     AttrName = "poster"
+	AttrGroup      AttrName = "group"
+	AttrUsenetDate AttrName = "usenetdate"
+	AttrPassword   AttrName = "password"
+	AttrComments   AttrName = "comments"
+)
+
+// knownAttrs are the attributes with a constant above, which the library
+// knows about.
+var knownAttrs = map[AttrName]bool{
+	AttrSize: true, AttrCategory: true, AttrFiles: true, AttrGrabs: true,
+	AttrSeeders: true, AttrLeechers: true, AttrPeers: true, AttrInfoHash: true,
+	AttrMagnetURL: true, AttrDownloadVolumeFactor: true, AttrUploadVolumeFactor: true,
+	AttrMinimumRatio: true, AttrMinimumSeedTime: true, AttrInfoURL: true,
+
+	AttrIMDB: true, AttrIMDBID: true, AttrTMDBID: true, AttrTVDBID: true,
+	AttrTVMazeID: true, AttrRageID: true, AttrTVRageID: true, AttrTraktID: true,
+	AttrDoubanID: true,
+
+	AttrSeason: true, AttrEpisode: true, AttrYear: true, AttrGenre: true,
+	AttrTeam: true, AttrTag: true,
+
+	AttrLanguage: true, AttrSubs: true,
+
+	AttrCoverURL: true, AttrBookTitle: true, AttrAuthor: true, AttrPublisher: true,
+	AttrArtist: true, AttrAlbum: true, AttrLabel: true, AttrTrack: true,
+
+	AttrPoster: true, AttrGroup: true, AttrUsenetDate: true, AttrPassword: true,
+	AttrComments: true,
+}
+
+// MetaUnknownAttrs is the Meta key holding the unknown attribute names of an
+// item when Config.StrictAttrs is set.
+const MetaUnknownAttrs = "unknown_attrs"
+
+// UnknownAttrs returns the names of the attributes of the item the library
+// has no constant for, e.g. ones added by new tracker definitions.
+func (i FeedItem) UnknownAttrs() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, attr := range i.Attr {
+		if knownAttrs[AttrName(attr.Name)] || seen[attr.Name] {
+			continue
+		}
+
+		seen[attr.Name] = true
+		out = append(out, attr.Name)
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+type attrReport struct {
+	mu      sync.Mutex
+	unknown map[string]map[string]bool
+}
+
+// checkAttrs records the unknown attributes of items in strict mode, logging
+// each the first time an indexer sends it.
+func (c *Client) checkAttrs(indexer string, items []FeedItem) {
+	if !c.cfg.StrictAttrs {
+		return
+	}
+
+	for i := range items {
+		names := items[i].UnknownAttrs()
+		if len(names) == 0 {
+			continue
+		}
+
+		// cached items share their map, leave it untouched
+		meta := make(map[string]interface{}, len(items[i].Meta)+1)
+		for k, v := range items[i].Meta {
+			meta[k] = v
+		}
+
+		meta[MetaUnknownAttrs] = names
+		items[i].Meta = meta
+
+		c.attrs.mu.Lock()
+		if c.attrs.unknown == nil {
+			c.attrs.unknown = make(map[string]map[string]bool)
+		}
+
+		if c.attrs.unknown[indexer] == nil {
+			c.attrs.unknown[indexer] = make(map[string]bool)
+		}
+
+		var added []string
+		for _, name := range names {
+			if !c.attrs.unknown[indexer][name] {
+				c.attrs.unknown[indexer][name] = true
+				added = append(added, name)
+			}
+		}
+		c.attrs.mu.Unlock()
+
+		if len(added) > 0 {
+			c.log.Warn("unknown attributes", "indexer", indexer, "attrs", added)
+		}
+	}
+}
+
+// UnknownAttrs returns the unknown attribute names seen per indexer since the
+// client was created, when Config.StrictAttrs is set.
+func (c *Client) UnknownAttrs() map[string][]string {
+	c.attrs.mu.Lock()
+	defer c.attrs.mu.Unlock()
+
+	out := make(map[string][]string, len(c.attrs.unknown))
+	for indexer, names := range c.attrs.unknown {
+		for name := range names {
+			out[indexer] = append(out[indexer], name)
+		}
+
+		sort.Strings(out[indexer])
+	}
+
+	return out
+}
@@ -0,0 +1,108 @@
+package jackett
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var (
+	ErrBackendDown = errors.Sentinel("backend down")
+
+	// consecutive refused connections before a backend is considered down
+	BackendDownThreshold = 3
+
+	BackendMinBackoff = 5 * time.Second
+	BackendMaxBackoff = 5 * time.Minute
+)
+
+// backendState tracks whether the host refuses connections, e.g. while
+// Jackett restarts, so requests fail fast instead of piling up retries.
+type backendState struct {
+	mu      sync.Mutex
+	refused int
+	down    bool
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// backendAllow returns ErrBackendDown while the backend is down, letting a single
+// probe request through every time the backoff expires.
+func (c *Client) backendAllow() error {
+	b := &c.backend
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.down {
+		return nil
+	}
+
+	now := c.clock.Now()
+	if now.Before(b.retryAt) {
+		return errors.Wrap(ErrBackendDown, "retrying at %v", b.retryAt.Format(time.RFC3339))
+	}
+
+	// hold back other requests while the probe is in flight
+	b.retryAt = now.Add(b.backoff)
+
+	return nil
+}
+
+// backendResult records the outcome of a request and reports whether the
+// backend is down. Only a received response brings it back up.
+func (c *Client) backendResult(err error) bool {
+	b := &c.backend
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.refused = 0
+
+		if b.down {
+			b.down = false
+			c.log.Info("backend up", "host", c.cfg.Host)
+			if c.cfg.OnBackendUp != nil {
+				go c.cfg.OnBackendUp(c.cfg.Host)
+			}
+		}
+
+		return false
+	}
+
+	// other failures, e.g. timeouts or DNS errors, don't tell whether the
+	// backend is back, leave the state as is
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+
+	b.refused++
+
+	if b.down {
+		b.backoff *= 2
+		if b.backoff > BackendMaxBackoff {
+			b.backoff = BackendMaxBackoff
+		}
+
+		b.retryAt = c.clock.Now().Add(b.backoff)
+		return true
+	}
+
+	if b.refused < BackendDownThreshold {
+		return false
+	}
+
+	b.down = true
+	b.backoff = BackendMinBackoff
+	b.retryAt = c.clock.Now().Add(b.backoff)
+
+	c.log.Warn("backend down", "host", c.cfg.Host, "err", err)
+	if c.cfg.OnBackendDown != nil {
+		go c.cfg.OnBackendDown(c.cfg.Host, err)
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package jackett_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+// refusedAddr returns an address nothing listens on.
+func refusedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	return addr
+}
+
+func TestBackendDown(t *testing.T) {
+	down := make(chan string, 1)
+
+	client := jackett.NewClient(jackett.Config{
+		Host:          "http://" + refusedAddr(t),
+		APIKey:        "apikey",
+		DisableRetry:  true,
+		OnBackendDown: func(host string, err error) { down <- host },
+	})
+
+	ctx := context.Background()
+
+	for i := 0; i < jackett.BackendDownThreshold; i++ {
+		_, err := client.GetIndexersCtx(ctx)
+		require.Error(t, err)
+	}
+
+	_, err := client.GetIndexersCtx(ctx)
+	assert.ErrorIs(t, err, jackett.ErrBackendDown)
+
+	select {
+	case <-down:
+	case <-time.After(time.Second):
+		t.Fatal("backend down was not reported")
+	}
+}
+
+func TestBackendDownIgnoresOtherHosts(t *testing.T) {
+	srv := jackettest.NewServer("apikey")
+	defer srv.Close()
+
+	srv.AddIndexer(jackettest.NewIndexer("tracker", "Tracker", 2000))
+
+	client := srv.Client()
+	ctx := context.Background()
+
+	// a tracker refusing enclosure downloads
+	enclosure := "http://" + refusedAddr(t) + "/download/1.torrent"
+
+	for i := 0; i < jackett.BackendDownThreshold*2; i++ {
+		_, err := client.GetEnclosureCtx(ctx, enclosure)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, jackett.ErrBackendDown)
+	}
+
+	_, err := client.GetIndexersCtx(ctx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,460 @@
+// Package boltstore implements the store interfaces of the jackett package
+// on top of a bbolt database, a single file needing neither a server nor
+// cgo.
+//
+//	store, err := boltstore.Open("jackett.db", nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//
+//	client := jackett.NewClient(jackett.Config{Host: host, APIKey: key, Cache: store})
+package boltstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// SchemaVersion is the version of the buckets, bumped on incompatible
+// changes. Databases of older versions are migrated when opened, newer ones
+// fail with jackett.ErrUnsupportedVersion.
+const SchemaVersion = 1
+
+// migrations upgrade the buckets of a version to the next one, keyed by the
+// version they upgrade from.
+var migrations = map[int]func(tx *bolt.Tx) error{}
+
+var (
+	metaBucket       = []byte("meta")
+	seenBucket       = []byte("seen")
+	blocklistBucket  = []byte("blocklist")
+	cacheBucket      = []byte("cache")
+	grabsBucket      = []byte("grabs")
+	watermarksBucket = []byte("watermarks")
+	actionsBucket    = []byte("actions")
+	samplesBucket    = []byte("samples")
+
+	versionKey = []byte("version")
+)
+
+var buckets = [][]byte{
+	seenBucket, blocklistBucket, cacheBucket, grabsBucket,
+	watermarksBucket, actionsBucket, samplesBucket,
+}
+
+// Store implements SeenStore, Blocklist, Cache, GrabQueue, WatermarkStore,
+// CooldownStore, BudgetStore and SampleStore of the jackett package. It is
+// safe for concurrent use.
+type Store struct {
+	db    *bolt.DB
+	codec jackett.Codec
+}
+
+// Open opens the database at path, creating it if needed, see New. Close the
+// store when done.
+func Open(path string, codec jackett.Codec) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open %v", path)
+	}
+
+	s, err := New(db, codec)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// New creates the buckets of the store in db if needed and migrates buckets
+// of older versions to SchemaVersion. A nil codec defaults to
+// jackett.JSONCodec.
+func New(db *bolt.DB, codec jackett.Codec) (*Store, error) {
+	if codec == nil {
+		codec = jackett.JSONCodec
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		version := SchemaVersion
+		if v := meta.Get(versionKey); v != nil {
+			if version, err = strconv.Atoi(string(v)); err != nil {
+				return errors.Wrap(err, "could not read schema version")
+			}
+		}
+
+		if version < 1 || version > SchemaVersion {
+			return errors.Wrap(jackett.ErrUnsupportedVersion, "bolt schema version %d, supported up to %d", version, SchemaVersion)
+		}
+
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		for ; version < SchemaVersion; version++ {
+			if migrate := migrations[version]; migrate != nil {
+				if err := migrate(tx); err != nil {
+					return errors.Wrap(err, "could not migrate schema from version %d", version)
+				}
+			}
+		}
+
+		return meta.Put(versionKey, []byte(strconv.Itoa(version)))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create schema")
+	}
+
+	return &Store{db: db, codec: codec}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// join builds the key of a value in a bucket shared by several feeds,
+// indexers or releases.
+func join(prefix string, parts ...[]byte) []byte {
+	key := append([]byte(prefix), 0)
+	for _, p := range parts {
+		key = append(key, p...)
+	}
+
+	return key
+}
+
+func uint64Bytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+// timeBytes encodes t sortable, times before 1970, e.g. the zero time, as
+// the earliest.
+func timeBytes(t time.Time) []byte {
+	n := t.UnixNano()
+	if n < 0 || t.Year() < 1970 {
+		n = 0
+	}
+
+	return uint64Bytes(uint64(n))
+}
+
+func (s *Store) Seen(feed, key string) (bool, error) {
+	var seen bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		seen = tx.Bucket(seenBucket).Get(join(feed, []byte(key))) != nil
+		return nil
+	})
+
+	return seen, err
+}
+
+func (s *Store) MarkSeen(feed, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(seenBucket).Put(join(feed, []byte(key)), []byte{})
+	})
+}
+
+func (s *Store) Blocked(key string) (bool, error) {
+	var blocked bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		blocked = tx.Bucket(blocklistBucket).Get([]byte(key)) != nil
+		return nil
+	})
+
+	return blocked, err
+}
+
+func (s *Store) Block(key, reason string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(blocklistBucket).Put([]byte(key), []byte(reason))
+	})
+}
+
+// Get returns the cached result with the key. Cached results are stored
+// after their expiry time, so PurgeExpired needn't decode them.
+func (s *Store) Get(key string) (jackett.CachedResult, bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(cacheBucket).Get([]byte(key)); len(v) >= 8 {
+			data = append([]byte(nil), v[8:]...)
+		}
+
+		return nil
+	})
+	if err != nil || data == nil {
+		return jackett.CachedResult{}, false, err
+	}
+
+	var result jackett.CachedResult
+	if err := s.codec.Unmarshal(data, &result); err != nil {
+		return jackett.CachedResult{}, false, errors.Wrap(err, "could not decode cached result: %v", key)
+	}
+
+	return result, true, nil
+}
+
+func (s *Store) Set(result jackett.CachedResult) error {
+	data, err := s.codec.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "could not encode cached result: %v", result.Key)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Put([]byte(result.Key), append(timeBytes(result.Expires), data...))
+	})
+}
+
+func (s *Store) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Delete([]byte(key))
+	})
+}
+
+// PurgeExpired deletes the cached results expired before now.
+func (s *Store) PurgeExpired(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(cacheBucket)
+
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if len(v) < 8 || int64(binary.BigEndian.Uint64(v)) < now.UnixNano() {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return deleteKeys(b, expired)
+	})
+}
+
+func deleteKeys(b *bolt.Bucket, keys [][]byte) error {
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) Push(item jackett.FeedItem) error {
+	data, err := s.codec.Marshal(item)
+	if err != nil {
+		return errors.Wrap(err, "could not encode item: %v", item.Title)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(grabsBucket)
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return b.Put(uint64Bytes(id), data)
+	})
+}
+
+func (s *Store) Pop() (jackett.FeedItem, bool, error) {
+	var data []byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(grabsBucket).Cursor()
+
+		k, v := c.First()
+		if k == nil {
+			return nil
+		}
+
+		data = append([]byte(nil), v...)
+		return c.Delete()
+	})
+	if err != nil || data == nil {
+		return jackett.FeedItem{}, false, err
+	}
+
+	// undecodable items are dropped rather than blocking the queue
+	var item jackett.FeedItem
+	if err := s.codec.Unmarshal(data, &item); err != nil {
+		return jackett.FeedItem{}, false, errors.Wrap(err, "could not decode queued item")
+	}
+
+	return item, true, nil
+}
+
+func (s *Store) Watermark(feed string) (jackett.Watermark, bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(watermarksBucket).Get([]byte(feed)); v != nil {
+			data = append([]byte(nil), v...)
+		}
+
+		return nil
+	})
+	if err != nil || data == nil {
+		return jackett.Watermark{}, false, err
+	}
+
+	var mark jackett.Watermark
+	if err := s.codec.Unmarshal(data, &mark); err != nil {
+		return jackett.Watermark{}, false, errors.Wrap(err, "could not decode watermark: %v", feed)
+	}
+
+	return mark, true, nil
+}
+
+func (s *Store) SetWatermark(feed string, mark jackett.Watermark) error {
+	data, err := s.codec.Marshal(mark)
+	if err != nil {
+		return errors.Wrap(err, "could not encode watermark: %v", feed)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(watermarksBucket).Put([]byte(feed), data)
+	})
+}
+
+// CountActions counts the actions of the key recorded at or after since.
+// Actions are keyed by their key, the time they were recorded at and a
+// sequence number, so they are a range of the bucket.
+func (s *Store) CountActions(key string, since time.Time) (int, error) {
+	prefix := join(key)
+
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(actionsBucket).Cursor()
+		for k, _ := c.Seek(join(key, timeBytes(since))); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			n++
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+func (s *Store) RecordAction(key string, at time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(actionsBucket)
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return b.Put(join(key, timeBytes(at), uint64Bytes(seq)), []byte{})
+	})
+}
+
+// PurgeActions deletes the actions recorded before t, which no longer count
+// towards cooldowns shorter than now - t.
+func (s *Store) PurgeActions(t time.Time) error {
+	return s.purgeBefore(actionsBucket, t)
+}
+
+// purgeBefore deletes the values of the bucket keyed by join with a time and
+// sequence number recorded before t.
+func (s *Store) purgeBefore(bucket []byte, t time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		var old [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			if len(k) < 16 {
+				return nil
+			}
+
+			at := int64(binary.BigEndian.Uint64(k[len(k)-16:]))
+			if at < t.UnixNano() {
+				old = append(old, append([]byte(nil), k...))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return deleteKeys(b, old)
+	})
+}
+
+// RecordSample stores the sample keyed by the guid of the release, the time
+// it was taken at and a sequence number.
+func (s *Store) RecordSample(sample jackett.SwarmSample) error {
+	data, err := s.codec.Marshal(sample)
+	if err != nil {
+		return errors.Wrap(err, "could not encode sample: %v", sample.GUID)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(samplesBucket)
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return b.Put(join(sample.GUID, timeBytes(sample.At), uint64Bytes(seq)), data)
+	})
+}
+
+// Samples returns the samples of the release with the guid taken at or
+// after since, oldest first.
+func (s *Store) Samples(guid string, since time.Time) ([]jackett.SwarmSample, error) {
+	prefix := join(guid)
+
+	var samples []jackett.SwarmSample
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(samplesBucket).Cursor()
+		for k, v := c.Seek(join(guid, timeBytes(since))); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var sample jackett.SwarmSample
+			if err := s.codec.Unmarshal(v, &sample); err != nil {
+				return errors.Wrap(err, "could not decode sample: %v", guid)
+			}
+
+			samples = append(samples, sample)
+		}
+
+		return nil
+	})
+
+	return samples, err
+}
+
+// PurgeSamples deletes the samples taken before t.
+func (s *Store) PurgeSamples(t time.Time) error {
+	return s.purgeBefore(samplesBucket, t)
+}
+
+var (
+	_ jackett.SeenStore      = (*Store)(nil)
+	_ jackett.Blocklist      = (*Store)(nil)
+	_ jackett.Cache          = (*Store)(nil)
+	_ jackett.GrabQueue      = (*Store)(nil)
+	_ jackett.WatermarkStore = (*Store)(nil)
+	_ jackett.CooldownStore  = (*Store)(nil)
+	_ jackett.BudgetStore    = (*Store)(nil)
+	_ jackett.SampleStore    = (*Store)(nil)
+)
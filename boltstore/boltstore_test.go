@@ -0,0 +1,291 @@
+package boltstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/boltstore"
+)
+
+var codecs = []struct {
+	name  string
+	codec jackett.Codec
+}{
+	{"json", jackett.JSONCodec},
+	{"gob", jackett.GobCodec},
+}
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// openStore opens a store in a temporary directory, closed with the test.
+func openStore(t *testing.T, codec jackett.Codec) (*boltstore.Store, string) {
+	path := filepath.Join(t.TempDir(), "jackett.db")
+
+	store, err := boltstore.Open(path, codec)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	return store, path
+}
+
+func TestSeenAndBlocklist(t *testing.T) {
+	store, _ := openStore(t, nil)
+
+	require.NoError(t, store.MarkSeen("tv", "a"))
+	require.NoError(t, store.Block("b", "fake"))
+
+	tests := []struct {
+		feed, key     string
+		seen, blocked bool
+	}{
+		{feed: "tv", key: "a", seen: true},
+		{feed: "movies", key: "a"},
+		{feed: "tv", key: "b", blocked: true},
+
+		// feed and key don't run together
+		{feed: "t", key: "va"},
+	}
+
+	for _, tt := range tests {
+		seen, err := store.Seen(tt.feed, tt.key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.seen, seen, "%v/%v", tt.feed, tt.key)
+
+		blocked, err := store.Blocked(tt.key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.blocked, blocked, tt.key)
+	}
+}
+
+func TestCache(t *testing.T) {
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			store, _ := openStore(t, c.codec)
+
+			var rss jackett.Rss
+			rss.Channel.Item = []jackett.FeedItem{{Title: "Show S01E01", Guid: "a"}}
+
+			results := []jackett.CachedResult{
+				{Key: "fresh", Rss: rss, Expires: start.Add(time.Hour)},
+				{Key: "expired", Rss: rss, Expires: start.Add(-time.Hour)},
+				{Key: "deleted", Rss: rss, Expires: start.Add(time.Hour)},
+				{Key: "zero", Rss: rss},
+			}
+
+			for _, r := range results {
+				require.NoError(t, store.Set(r))
+			}
+
+			require.NoError(t, store.Delete("deleted"))
+			require.NoError(t, store.PurgeExpired(start))
+
+			tests := []struct {
+				key string
+				ok  bool
+			}{
+				{key: "fresh", ok: true},
+				{key: "expired"},
+				{key: "deleted"},
+				{key: "zero"},
+				{key: "missing"},
+			}
+
+			for _, tt := range tests {
+				got, ok, err := store.Get(tt.key)
+				require.NoError(t, err)
+				require.Equal(t, tt.ok, ok, tt.key)
+
+				if ok {
+					assert.Equal(t, tt.key, got.Key)
+					assert.True(t, results[0].Expires.Equal(got.Expires))
+					assert.Equal(t, rss.Channel.Item, got.Rss.Channel.Item)
+				}
+			}
+		})
+	}
+}
+
+func TestGrabQueue(t *testing.T) {
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			store, path := openStore(t, c.codec)
+
+			for _, title := range []string{"first", "second", "third"} {
+				require.NoError(t, store.Push(jackett.FeedItem{Title: title}))
+			}
+
+			item, ok, err := store.Pop()
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "first", item.Title)
+
+			// the queue survives reopening
+			require.NoError(t, store.Close())
+
+			store, err = boltstore.Open(path, c.codec)
+			require.NoError(t, err)
+			defer store.Close()
+
+			for _, want := range []string{"second", "third"} {
+				item, ok, err := store.Pop()
+				require.NoError(t, err)
+				require.True(t, ok)
+				assert.Equal(t, want, item.Title)
+			}
+
+			_, ok, err = store.Pop()
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestWatermarks(t *testing.T) {
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			store, _ := openStore(t, c.codec)
+
+			_, ok, err := store.Watermark("tv")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			mark := jackett.Watermark{PubDate: start, Keys: []string{"a", "b"}}
+			require.NoError(t, store.SetWatermark("tv", mark))
+
+			got, ok, err := store.Watermark("tv")
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.True(t, start.Equal(got.PubDate))
+			assert.Equal(t, mark.Keys, got.Keys)
+
+			_, ok, err = store.Watermark("movies")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestActions(t *testing.T) {
+	store, _ := openStore(t, nil)
+
+	for _, at := range []time.Duration{-2 * time.Hour, -time.Hour, -time.Minute, 0, 0} {
+		require.NoError(t, store.RecordAction("rule/a", start.Add(at)))
+	}
+
+	require.NoError(t, store.RecordAction("rule/ab", start))
+	require.NoError(t, store.RecordAction("rule/b", time.Time{}))
+
+	tests := []struct {
+		name  string
+		key   string
+		since time.Time
+		want  int
+	}{
+		{name: "all", key: "rule/a", since: start.Add(-24 * time.Hour), want: 5},
+		{name: "inclusive", key: "rule/a", since: start.Add(-time.Hour), want: 4},
+		{name: "same time", key: "rule/a", since: start, want: 2},
+		{name: "none since", key: "rule/a", since: start.Add(time.Second)},
+		{name: "other key", key: "rule/ab", since: time.Time{}, want: 1},
+		{name: "zero time", key: "rule/b", since: time.Time{}, want: 1},
+		{name: "missing", key: "rule/c", since: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := store.CountActions(tt.key, tt.since)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+
+	require.NoError(t, store.PurgeActions(start.Add(-time.Hour)))
+
+	n, err := store.CountActions("rule/a", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+}
+
+func TestSamples(t *testing.T) {
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			store, _ := openStore(t, c.codec)
+
+			// recorded out of order
+			for _, at := range []time.Duration{time.Hour, 0, 2 * time.Hour} {
+				sample := jackett.SwarmSample{Indexer: "tracker", GUID: "a", At: start.Add(at), Seeders: int(at / time.Hour), Found: true}
+				require.NoError(t, store.RecordSample(sample))
+			}
+
+			require.NoError(t, store.RecordSample(jackett.SwarmSample{Indexer: "tracker", GUID: "b", At: start}))
+
+			samples, err := store.Samples("a", start.Add(time.Hour))
+			require.NoError(t, err)
+			require.Len(t, samples, 2)
+			assert.Equal(t, 1, samples[0].Seeders)
+			assert.Equal(t, 2, samples[1].Seeders)
+			assert.True(t, samples[0].Found)
+			assert.True(t, start.Add(time.Hour).Equal(samples[0].At))
+
+			require.NoError(t, store.PurgeSamples(start.Add(2*time.Hour)))
+
+			samples, err = store.Samples("a", time.Time{})
+			require.NoError(t, err)
+			require.Len(t, samples, 1)
+			assert.Equal(t, 2, samples[0].Seeders)
+
+			samples, err = store.Samples("b", time.Time{})
+			require.NoError(t, err)
+			assert.Empty(t, samples)
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		err     error
+	}{
+		{name: "new"},
+		{name: "current", version: "1"},
+		{name: "newer", version: "2", err: jackett.ErrUnsupportedVersion},
+		{name: "invalid", version: "0", err: jackett.ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := bolt.Open(filepath.Join(t.TempDir(), "jackett.db"), 0o600, nil)
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tt.version != "" {
+				require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+					meta, err := tx.CreateBucketIfNotExists([]byte("meta"))
+					if err != nil {
+						return err
+					}
+
+					return meta.Put([]byte("version"), []byte(tt.version))
+				}))
+			}
+
+			_, err = boltstore.New(db, nil)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, db.View(func(tx *bolt.Tx) error {
+				assert.Equal(t, "1", string(tx.Bucket([]byte("meta")).Get([]byte("version"))))
+				return nil
+			}))
+		})
+	}
+}
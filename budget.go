@@ -0,0 +1,115 @@
+package jackett
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var ErrBudgetExhausted = errors.Sentinel("search budget exhausted")
+
+// SearchBudget is the number of searches a tracker allows per day, e.g. the
+// API limits of private trackers. Days start at midnight UTC. Caps requests
+// and results served from the cache don't count.
+type SearchBudget struct {
+	Searches int `json:"searches"`
+
+	// wait for the next day instead of failing with a BudgetError
+	Queue bool `json:"queue,omitempty"`
+}
+
+func (b SearchBudget) validate() error {
+	if b.Searches <= 0 {
+		return errors.New("invalid search budget: %v searches per day", b.Searches)
+	}
+
+	return nil
+}
+
+// BudgetStore persists the searches counted against budgets, so they hold
+// across restarts. Every CooldownStore is one, e.g. SQLStore.
+type BudgetStore interface {
+	CountActions(key string, since time.Time) (int, error)
+	RecordAction(key string, at time.Time) error
+}
+
+// BudgetError is returned for searches over the budget of their indexer. It
+// matches ErrBudgetExhausted.
+type BudgetError struct {
+	Indexer string
+	Limit   int
+
+	// start of the next day, when searches are allowed again
+	ResetAt time.Time
+}
+
+func (e *BudgetError) Error() string {
+	return "search budget exhausted: " + e.Indexer + " allows " + strconv.Itoa(e.Limit) +
+		" searches per day, next at " + e.ResetAt.Format(time.RFC3339)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExhausted
+}
+
+type budgetState struct {
+	mu    sync.Mutex
+	store BudgetStore
+}
+
+func newBudgetState(cfg Config) *budgetState {
+	store := cfg.BudgetStore
+	if store == nil {
+		store = newMemoryCooldowns(24 * time.Hour)
+	}
+
+	return &budgetState{store: store}
+}
+
+// spendBudget counts a search of the indexer against its budget, if it has
+// one. Over budget it returns a BudgetError, or waits for the next day when
+// the budget queues.
+func (c *Client) spendBudget(ctx context.Context, indexer string) error {
+	budget, ok := c.cfg.SearchBudgets[indexer]
+	if !ok {
+		return nil
+	}
+
+	key := "search/" + indexer
+
+	for {
+		now := c.clock.Now().UTC()
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		reset := day.AddDate(0, 0, 1)
+
+		c.budgets.mu.Lock()
+		n, err := c.budgets.store.CountActions(key, day)
+		if err == nil && n < budget.Searches {
+			err = c.budgets.store.RecordAction(key, now)
+			c.budgets.mu.Unlock()
+
+			return errors.Wrap(err, "could not record search")
+		}
+		c.budgets.mu.Unlock()
+
+		if err != nil {
+			return errors.Wrap(err, "could not count searches")
+		}
+
+		berr := &BudgetError{Indexer: indexer, Limit: budget.Searches, ResetAt: reset}
+		if !budget.Queue {
+			return berr
+		}
+
+		c.log.Info("search budget exhausted, waiting", "indexer", indexer, "until", reset)
+
+		select {
+		case <-c.clock.After(reset.Sub(now)):
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "waiting for search budget of %v", indexer)
+		}
+	}
+}
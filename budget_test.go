@@ -0,0 +1,126 @@
+package jackett
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+func TestSpendBudget(t *testing.T) {
+	type step struct {
+		indexer string
+
+		// the clock advances before the search
+		advance time.Duration
+
+		exhausted bool
+	}
+
+	tests := []struct {
+		name    string
+		budgets map[string]SearchBudget
+		steps   []step
+	}{
+		{
+			name:    "without budget",
+			budgets: map[string]SearchBudget{"other": {Searches: 1}},
+			steps: []step{
+				{indexer: "tracker"},
+				{indexer: "tracker"},
+				{indexer: "tracker"},
+			},
+		},
+		{
+			name:    "exhausted",
+			budgets: map[string]SearchBudget{"tracker": {Searches: 2}},
+			steps: []step{
+				{indexer: "tracker"},
+				{indexer: "tracker"},
+				{indexer: "tracker", exhausted: true},
+				{indexer: "tracker", advance: 11 * time.Hour, exhausted: true},
+			},
+		},
+		{
+			name:    "per indexer",
+			budgets: map[string]SearchBudget{"tracker": {Searches: 1}, "other": {Searches: 1}},
+			steps: []step{
+				{indexer: "tracker"},
+				{indexer: "other"},
+				{indexer: "tracker", exhausted: true},
+			},
+		},
+		{
+			name:    "reset at midnight",
+			budgets: map[string]SearchBudget{"tracker": {Searches: 1}},
+			steps: []step{
+				{indexer: "tracker"},
+				{indexer: "tracker", advance: 11*time.Hour + 59*time.Minute, exhausted: true},
+				{indexer: "tracker", advance: time.Minute},
+				{indexer: "tracker", exhausted: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+
+			c := NewClient(Config{Host: "http://127.0.0.1:9117", APIKey: "apikey", SearchBudgets: tt.budgets})
+			c.clock = clock
+
+			for n, s := range tt.steps {
+				clock.Advance(s.advance)
+
+				err := c.spendBudget(context.Background(), s.indexer)
+				if !s.exhausted {
+					require.NoError(t, err, "step %d", n)
+					continue
+				}
+
+				var berr *BudgetError
+				require.True(t, errors.As(err, &berr), "step %d: %v", n, err)
+				assert.True(t, errors.Is(err, ErrBudgetExhausted))
+				assert.Equal(t, s.indexer, berr.Indexer)
+				assert.Equal(t, tt.budgets[s.indexer].Searches, berr.Limit)
+
+				now := clock.Now()
+				assert.Equal(t, time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC), berr.ResetAt)
+			}
+		})
+	}
+}
+
+// TestSpendBudgetQueued expects queued searches over budget to wait for the
+// next day.
+func TestSpendBudgetQueued(t *testing.T) {
+	clock := newFakeClock()
+
+	c := NewClient(Config{
+		Host:          "http://127.0.0.1:9117",
+		APIKey:        "apikey",
+		SearchBudgets: map[string]SearchBudget{"tracker": {Searches: 1, Queue: true}},
+	})
+	c.clock = clock
+
+	require.NoError(t, c.spendBudget(context.Background(), "tracker"))
+	require.NoError(t, c.spendBudget(context.Background(), "tracker"))
+
+	assert.Equal(t, time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), clock.Now())
+}
+
+func TestSearchBudgetValidate(t *testing.T) {
+	for _, searches := range []int{0, -1} {
+		cfg := Config{
+			Host:          "http://127.0.0.1:9117",
+			APIKey:        "apikey",
+			SearchBudgets: map[string]SearchBudget{"tracker": {Searches: searches}},
+		}
+
+		assert.ErrorContains(t, cfg.Validate(), "indexer tracker: invalid search budget")
+	}
+}
@@ -0,0 +1,220 @@
+package jackett
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idParams are the torznab params identifying content regardless of how the
+// query itself was phrased.
+var idParams = []string{"imdbid", "tmdbid", "tvdbid", "tvmazeid", "rid", "traktid", "doubanid"}
+
+const cacheSweepSize = 1024
+
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	rss     Rss
+	expires time.Time
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]cacheEntry)}
+}
+
+func (rc *resultCache) get(key string, now time.Time) (Rss, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return Rss{}, false
+	}
+
+	if now.After(entry.expires) {
+		delete(rc.entries, key)
+		return Rss{}, false
+	}
+
+	return copyRss(entry.rss), true
+}
+
+func (rc *resultCache) set(key string, rss Rss, now time.Time, ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	// sweep expired entries now and then so unrequested searches don't pile up
+	if len(rc.entries) >= cacheSweepSize {
+		for k, entry := range rc.entries {
+			if now.After(entry.expires) {
+				delete(rc.entries, k)
+			}
+		}
+	}
+
+	rc.entries[key] = cacheEntry{rss: copyRss(rss), expires: now.Add(ttl)}
+}
+
+func (rc *resultCache) delete(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	delete(rc.entries, key)
+}
+
+func (rc *resultCache) clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries = make(map[string]cacheEntry)
+}
+
+func (rc *resultCache) snapshot(now time.Time) []CachedResult {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	results := make([]CachedResult, 0, len(rc.entries))
+	for k, entry := range rc.entries {
+		if now.After(entry.expires) {
+			continue
+		}
+
+		results = append(results, CachedResult{Key: k, Rss: copyRss(entry.rss), Expires: entry.expires})
+	}
+
+	return results
+}
+
+func (rc *resultCache) restore(results []CachedResult, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, r := range results {
+		if now.After(r.Expires) {
+			continue
+		}
+
+		rc.entries[r.Key] = cacheEntry{rss: copyRss(r.Rss), expires: r.Expires}
+	}
+}
+
+func copyRss(rss Rss) Rss {
+	rss.Channel.Item = append([]FeedItem(nil), rss.Channel.Item...)
+	return rss
+}
+
+// cacheKey keys ID based searches by the identifiers, search type, season,
+// episode and categories only, so lookups for the same content from
+// different callers share an entry however they phrased the query. Other
+// searches are keyed by all of their params but the api key.
+func cacheKey(indexer string, opts map[string]string) string {
+	var ids []string
+	for _, p := range idParams {
+		if v := opts[p]; v != "" {
+			ids = append(ids, p+"="+v)
+		}
+	}
+
+	var parts []string
+	if len(ids) > 0 {
+		parts = append(ids,
+			"t="+opts["t"],
+			"season="+opts["season"],
+			"ep="+opts["ep"],
+			"cat="+normalizeCategories(opts["cat"]),
+			"offset="+opts["offset"],
+			"limit="+opts["limit"],
+		)
+	} else {
+		for k, v := range opts {
+			if k == "apikey" {
+				continue
+			}
+
+			if k == "cat" {
+				v = normalizeCategories(v)
+			}
+
+			parts = append(parts, k+"="+v)
+		}
+
+		sort.Strings(parts)
+	}
+
+	return indexer + "?" + strings.Join(parts, "&")
+}
+
+func normalizeCategories(s string) string {
+	cats := parseCategories(s)
+	sort.Ints(cats)
+
+	out := make([]string, 0, len(cats))
+	for _, cat := range cats {
+		out = append(out, strconv.Itoa(cat))
+	}
+
+	return strings.Join(out, ",")
+}
+
+// cachedResults looks key up in the in-memory cache, then in Config.Cache.
+func (c *Client) cachedResults(key string) (Rss, bool) {
+	now := c.clock.Now()
+	if rss, ok := c.cache.get(key, now); ok {
+		return rss, true
+	}
+
+	if c.cfg.Cache == nil {
+		return Rss{}, false
+	}
+
+	result, ok, err := c.cfg.Cache.Get(key)
+	if err != nil {
+		c.log.Warn("cache error", "key", key, "err", err)
+		return Rss{}, false
+	}
+
+	if !ok || now.After(result.Expires) {
+		return Rss{}, false
+	}
+
+	c.cache.set(key, result.Rss, now, result.Expires.Sub(now))
+	return result.Rss, true
+}
+
+func (c *Client) cacheResults(key string, rss Rss) {
+	now := c.clock.Now()
+	c.cache.set(key, rss, now, c.cacheTTL)
+
+	if c.cfg.Cache == nil {
+		return
+	}
+
+	result := CachedResult{Key: key, Rss: copyRss(rss), Expires: now.Add(c.cacheTTL)}
+	if err := c.cfg.Cache.Set(result); err != nil {
+		c.log.Warn("cache error", "key", key, "err", err)
+	}
+}
+
+// InvalidateCache drops the cached results of the given search.
+func (c *Client) InvalidateCache(indexer string, opts map[string]string) {
+	key := cacheKey(indexer, opts)
+	c.cache.delete(key)
+
+	if c.cfg.Cache != nil {
+		if err := c.cfg.Cache.Delete(key); err != nil {
+			c.log.Warn("cache error", "key", key, "err", err)
+		}
+	}
+}
+
+// ClearCache drops the in-memory results, the results of Config.Cache expire
+// on their own.
+func (c *Client) ClearCache() {
+	c.cache.clear()
+}
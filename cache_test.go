@@ -0,0 +1,173 @@
+package jackett_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+func newCachingServer(t *testing.T) (*jackettest.Server, *jackett.Client) {
+	srv := jackettest.NewServer("apikey")
+	t.Cleanup(srv.Close)
+
+	srv.AddIndexer(jackettest.NewIndexer("tracker", "Tracker", 5000),
+		jackettest.Item("Show S01E02 1080p", 5000, 10))
+
+	client := jackett.NewClient(jackett.Config{
+		Host:         srv.URL,
+		APIKey:       "apikey",
+		CacheTTL:     60,
+		DisableRetry: true,
+	})
+
+	return srv, client
+}
+
+func TestCacheSharedByContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  map[string]string
+		second map[string]string
+		shared bool
+	}{
+		{
+			name:   "id search with different queries",
+			first:  map[string]string{"t": "tvsearch", "tvdbid": "1", "season": "1", "ep": "2", "q": "Show"},
+			second: map[string]string{"t": "tvsearch", "tvdbid": "1", "season": "1", "ep": "2", "q": "show s01e02"},
+			shared: true,
+		},
+		{
+			name:   "id search with reordered categories",
+			first:  map[string]string{"t": "tvsearch", "tvdbid": "1", "cat": "5000,5040"},
+			second: map[string]string{"t": "tvsearch", "tvdbid": "1", "cat": "5040,5000", "extended": "1"},
+			shared: true,
+		},
+		{
+			name:   "id search of another episode",
+			first:  map[string]string{"t": "tvsearch", "tvdbid": "1", "season": "1", "ep": "2"},
+			second: map[string]string{"t": "tvsearch", "tvdbid": "1", "season": "1", "ep": "3"},
+		},
+		{
+			name:   "id search of another type",
+			first:  map[string]string{"t": "tvsearch", "imdbid": "tt1"},
+			second: map[string]string{"t": "movie", "imdbid": "tt1"},
+		},
+		{
+			name:   "text search with reordered categories",
+			first:  map[string]string{"t": "search", "q": "show", "cat": "5000,2000"},
+			second: map[string]string{"t": "search", "q": "show", "cat": "2000,5000"},
+			shared: true,
+		},
+		{
+			name:   "text search with different queries",
+			first:  map[string]string{"t": "search", "q": "show"},
+			second: map[string]string{"t": "search", "q": "show 1080p"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newCachingServer(t)
+			ctx := context.Background()
+
+			first, err := client.GetTorrentsCtx(ctx, "tracker", tt.first)
+			require.NoError(t, err)
+
+			second, err := client.GetTorrentsCtx(ctx, "tracker", tt.second)
+			require.NoError(t, err)
+
+			if tt.shared {
+				assert.Len(t, srv.Requests(), 1)
+				assert.Equal(t, first, second)
+			} else {
+				assert.Len(t, srv.Requests(), 2)
+			}
+		})
+	}
+}
+
+func TestInvalidateCache(t *testing.T) {
+	srv, client := newCachingServer(t)
+	ctx := context.Background()
+
+	opts := map[string]string{"t": "tvsearch", "tvdbid": "1", "q": "Show"}
+
+	_, err := client.GetTorrentsCtx(ctx, "tracker", opts)
+	require.NoError(t, err)
+
+	// invalidated by another phrasing of the same lookup
+	client.InvalidateCache("tracker", map[string]string{"t": "tvsearch", "tvdbid": "1"})
+
+	_, err = client.GetTorrentsCtx(ctx, "tracker", opts)
+	require.NoError(t, err)
+
+	assert.Len(t, srv.Requests(), 2)
+}
+
+func TestLoadCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name: "bare results",
+			data: `[{"Key":"tracker","Expires":"2999-01-01T00:00:00Z"}]`,
+		},
+		{
+			name:    "newer version",
+			data:    `{"version":99,"data":[]}`,
+			wantErr: jackett.ErrUnsupportedVersion,
+		},
+		{
+			name:    "unversioned",
+			data:    `{"data":[]}`,
+			wantErr: jackett.ErrUnsupportedVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newCachingServer(t)
+
+			err := client.LoadCache(strings.NewReader(tt.data), nil)
+			require.Error(t, err)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveCacheRoundTrip(t *testing.T) {
+	for _, codec := range []jackett.Codec{jackett.JSONCodec, jackett.GobCodec} {
+		srv, client := newCachingServer(t)
+		ctx := context.Background()
+
+		opts := map[string]string{"t": "tvsearch", "tvdbid": "1"}
+
+		want, err := client.GetTorrentsCtx(ctx, "tracker", opts)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, client.SaveCache(&buf, codec))
+
+		other, restored := newCachingServer(t)
+		require.NoError(t, restored.LoadCache(&buf, codec))
+
+		got, err := restored.GetTorrentsCtx(ctx, "tracker", opts)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		assert.Len(t, srv.Requests(), 1)
+		assert.Empty(t, other.Requests())
+	}
+}
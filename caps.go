@@ -0,0 +1,238 @@
+package jackett
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// Caps is a parsed torznab caps document.
+type Caps struct {
+	ServerTitle string     `json:"serverTitle,omitempty"`
+	Limits      CapsLimits `json:"limits"`
+
+	// available search modes keyed by SearchMode*
+	Modes map[string]SearchModeCaps `json:"modes"`
+
+	Categories []CapsCategory `json:"categories"`
+}
+
+type CapsLimits struct {
+	Default int `json:"default"`
+	Max     int `json:"max"`
+}
+
+type SearchModeCaps struct {
+	Params       []string `json:"params"`
+	SearchEngine string   `json:"searchEngine,omitempty"`
+}
+
+// CapsCategory is a category of the caps category tree, standard or tracker
+// specific.
+type CapsCategory struct {
+	ID      int            `json:"id"`
+	Name    string         `json:"name"`
+	Subcats []CapsCategory `json:"subcats,omitempty"`
+}
+
+func (c Caps) SupportsMode(mode string) bool {
+	_, ok := c.Modes[mode]
+	return ok
+}
+
+func (c Caps) Supports(mode, param string) bool {
+	for _, p := range c.Modes[mode].Params {
+		if p == param {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IDParams returns the id params, e.g. imdbid or tvdbid, supported in mode.
+func (c Caps) IDParams(mode string) []string {
+	var out []string
+	for _, p := range idParams {
+		if c.Supports(mode, p) {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// CategoryIDs returns the ids of all categories and subcategories.
+func (c Caps) CategoryIDs() []int {
+	var out []int
+	for _, cat := range c.Categories {
+		out = append(out, cat.ID)
+		for _, sub := range cat.Subcats {
+			out = append(out, sub.ID)
+		}
+	}
+
+	return out
+}
+
+type capsDocument struct {
+	XMLName xml.Name `xml:"caps"`
+	Server  struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Default string `xml:"default,attr"`
+		Max     string `xml:"max,attr"`
+	} `xml:"limits"`
+	Searching struct {
+		Modes []struct {
+			XMLName         xml.Name
+			Available       string `xml:"available,attr"`
+			SupportedParams string `xml:"supportedParams,attr"`
+			SearchEngine    string `xml:"searchEngine,attr"`
+		} `xml:",any"`
+	} `xml:"searching"`
+	Categories struct {
+		Category []capsCategoryElement `xml:"category"`
+	} `xml:"categories"`
+}
+
+type capsCategoryElement struct {
+	ID     string                `xml:"id,attr"`
+	Name   string                `xml:"name,attr"`
+	Subcat []capsCategoryElement `xml:"subcat"`
+}
+
+func (doc capsDocument) caps() Caps {
+	caps := Caps{
+		ServerTitle: doc.Server.Title,
+		Modes:       make(map[string]SearchModeCaps),
+	}
+
+	caps.Limits.Default, _ = strconv.Atoi(doc.Limits.Default)
+	caps.Limits.Max, _ = strconv.Atoi(doc.Limits.Max)
+
+	for _, mode := range doc.Searching.Modes {
+		if mode.Available != "yes" {
+			continue
+		}
+
+		caps.Modes[mode.XMLName.Local] = SearchModeCaps{
+			Params:       splitParams(mode.SupportedParams),
+			SearchEngine: mode.SearchEngine,
+		}
+	}
+
+	caps.Categories = capsCategories(doc.Categories.Category)
+
+	return caps
+}
+
+func capsCategories(elems []capsCategoryElement) []CapsCategory {
+	var out []CapsCategory
+	for _, e := range elems {
+		id, err := strconv.Atoi(e.ID)
+		if err != nil {
+			continue
+		}
+
+		out = append(out, CapsCategory{
+			ID:      id,
+			Name:    e.Name,
+			Subcats: capsCategories(e.Subcat),
+		})
+	}
+
+	return out
+}
+
+// ParsedCaps returns the caps of the indexer listing as parsed by
+// GetCapsForIndexerCtx.
+func (i Indexer) ParsedCaps() Caps {
+	caps := Caps{
+		ServerTitle: i.Caps.Server.Title,
+		Modes:       make(map[string]SearchModeCaps),
+	}
+
+	caps.Limits.Default, _ = strconv.Atoi(i.Caps.Limits.Default)
+	caps.Limits.Max, _ = strconv.Atoi(i.Caps.Limits.Max)
+
+	s := i.Caps.Searching
+	modes := []struct {
+		name      string
+		available string
+		params    string
+		engine    string
+	}{
+		{SearchModeSearch, s.Search.Available, s.Search.SupportedParams, s.Search.SearchEngine},
+		{SearchModeTV, s.TvSearch.Available, s.TvSearch.SupportedParams, s.TvSearch.SearchEngine},
+		{SearchModeMovie, s.MovieSearch.Available, s.MovieSearch.SupportedParams, s.MovieSearch.SearchEngine},
+		{SearchModeMusic, s.MusicSearch.Available, s.MusicSearch.SupportedParams, s.MusicSearch.SearchEngine},
+		{SearchModeAudio, s.AudioSearch.Available, s.AudioSearch.SupportedParams, s.AudioSearch.SearchEngine},
+		{SearchModeBook, s.BookSearch.Available, s.BookSearch.SupportedParams, s.BookSearch.SearchEngine},
+	}
+
+	for _, mode := range modes {
+		if mode.available != "yes" {
+			continue
+		}
+
+		caps.Modes[mode.name] = SearchModeCaps{
+			Params:       splitParams(mode.params),
+			SearchEngine: mode.engine,
+		}
+	}
+
+	for _, c := range i.Caps.Categories.Category {
+		id, err := strconv.Atoi(c.ID)
+		if err != nil {
+			continue
+		}
+
+		cat := CapsCategory{ID: id, Name: c.Name}
+		for _, sub := range c.Subcat {
+			if id, err := strconv.Atoi(sub.ID); err == nil {
+				cat.Subcats = append(cat.Subcats, CapsCategory{ID: id, Name: sub.Name})
+			}
+		}
+
+		caps.Categories = append(caps.Categories, cat)
+	}
+
+	return caps
+}
+
+func splitParams(s string) []string {
+	var params []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+// GetCapsCtx fetches the caps of the aggregate "all" indexer, or of the
+// server in DirectMode.
+func (c *Client) GetCapsCtx(ctx context.Context) (Caps, error) {
+	return c.GetCapsForIndexerCtx(ctx, "all")
+}
+
+func (c *Client) GetCapsForIndexerCtx(ctx context.Context, indexer string) (Caps, error) {
+	opts := map[string]string{
+		"t": "caps",
+	}
+
+	if len(c.cfg.APIKey) != 0 {
+		opts["apikey"] = c.cfg.APIKey
+	}
+
+	var doc capsDocument
+	if err := c.getTorznabInto(ctx, indexer, "", opts, &doc); err != nil {
+		return Caps{}, err
+	}
+
+	return doc.caps(), nil
+}
@@ -0,0 +1,75 @@
+package jackett_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+// TestParsedCapsMatchesFetched expects the caps of the indexer listing and
+// the ones fetched from the indexer to parse alike.
+func TestParsedCapsMatchesFetched(t *testing.T) {
+	srv := jackettest.NewServer("apikey")
+	defer srv.Close()
+
+	ind := jackettest.NewIndexer("tracker", "Tracker", 2000, 5000, 100001)
+	srv.AddIndexer(ind)
+
+	fetched, err := srv.Client().GetCapsForIndexerCtx(context.Background(), "tracker")
+	require.NoError(t, err)
+
+	parsed := ind.ParsedCaps()
+	assert.Equal(t, fetched, parsed)
+
+	assert.Equal(t, 100, parsed.Limits.Max)
+	assert.Equal(t, []int{2000, 5000, 100001}, parsed.CategoryIDs())
+	assert.Equal(t, []string{"imdbid", "tvdbid"}, parsed.IDParams(jackett.SearchModeTV))
+	assert.False(t, parsed.SupportsMode(jackett.SearchModeMusic))
+}
+
+func TestDiscoverCaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    bool
+		wantErr bool
+	}{
+		{name: "fetched"},
+		{name: "listing fallback", fail: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := jackettest.NewServer("apikey")
+			defer srv.Close()
+
+			ind := jackettest.NewIndexer("tracker", "Tracker", 2000)
+			srv.AddIndexer(ind)
+
+			multi := jackett.NewMultiClient(srv.Client())
+			_, err := multi.GetIndexersCtx(context.Background())
+			require.NoError(t, err)
+
+			if tt.fail {
+				srv.Fail("tracker", jackettest.Failure{Status: http.StatusServiceUnavailable})
+			}
+
+			matrix, err := multi.DiscoverCaps(context.Background())
+			require.NoError(t, err)
+			require.Len(t, matrix, 1)
+
+			ic := matrix[0]
+			assert.Equal(t, tt.wantErr, ic.Err != nil, ic.Err)
+			assert.Equal(t, srv.URL, ic.Host)
+			assert.Equal(t, ind.Capabilities().Modes, ic.Modes)
+			assert.Equal(t, []int{2000}, ic.Categories)
+			assert.Len(t, matrix.Supporting(jackett.SearchModeMovie, "imdbid"), 1)
+			assert.Empty(t, matrix.Supporting(jackett.SearchModeMusic, "q"))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package jackett
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Search modes as named in torznab caps.
+const (
+	SearchModeSearch = "search"
+	SearchModeTV     = "tv-search"
+	SearchModeMovie  = "movie-search"
+	SearchModeMusic  = "music-search"
+	SearchModeAudio  = "audio-search"
+	SearchModeBook   = "book-search"
+)
+
+// DiscoverConcurrency bounds the caps requests DiscoverCaps runs at once.
+var DiscoverConcurrency = 8
+
+// IndexerCapabilities summarizes the caps of an indexer.
+type IndexerCapabilities struct {
+	// host of the client the indexer is configured on
+	Host    string
+	Indexer string
+	Title   string
+
+	// supported params of every available search mode, e.g. tv-search: q, season, ep, tvdbid
+	Modes map[string][]string
+
+	Categories []int
+
+	// set when the caps could not be fetched and Modes and Categories are
+	// from the indexer listing instead
+	Err error
+
+	client *Client
+}
+
+func (ic IndexerCapabilities) SupportsMode(mode string) bool {
+	_, ok := ic.Modes[mode]
+	return ok
+}
+
+func (ic IndexerCapabilities) Supports(mode, param string) bool {
+	for _, p := range ic.Modes[mode] {
+		if p == param {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CapsMatrix holds the capabilities of many indexers, sorted by host and
+// indexer.
+type CapsMatrix []IndexerCapabilities
+
+// Supporting returns the indexers supporting param in mode, e.g.
+// Supporting(SearchModeMovie, "imdbid").
+func (m CapsMatrix) Supporting(mode, param string) CapsMatrix {
+	var out CapsMatrix
+	for _, ic := range m {
+		if ic.Supports(mode, param) {
+			out = append(out, ic)
+		}
+	}
+
+	return out
+}
+
+// Capabilities summarizes the caps of the indexer listing.
+func (i Indexer) Capabilities() IndexerCapabilities {
+	return newIndexerCapabilities(i, i.ParsedCaps())
+}
+
+// newIndexerCapabilities summarizes caps of the indexer.
+func newIndexerCapabilities(i Indexer, caps Caps) IndexerCapabilities {
+	ic := IndexerCapabilities{
+		Indexer:    i.ID,
+		Title:      i.Title,
+		Modes:      make(map[string][]string, len(caps.Modes)),
+		Categories: caps.CategoryIDs(),
+	}
+
+	for name, mode := range caps.Modes {
+		ic.Modes[name] = mode.Params
+	}
+
+	return ic
+}
+
+// DiscoverCaps fetches the caps of every configured indexer concurrently and
+// consolidates them into a capability matrix, which is also used to route
+// searches afterwards.
+func (m *MultiClient) DiscoverCaps(ctx context.Context) (CapsMatrix, error) {
+	indexers, err := m.loadIndexers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.discoverCaps(ctx, indexers)
+}
+
+// discoverCaps fetches the caps of the indexers and stores the matrix.
+func (m *MultiClient) discoverCaps(ctx context.Context, indexers map[*Client][]Indexer) (CapsMatrix, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		matrix CapsMatrix
+	)
+
+	sem := make(chan struct{}, DiscoverConcurrency)
+
+	for c, list := range indexers {
+		for _, ind := range list {
+			wg.Add(1)
+			go func(c *Client, ind Indexer) {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				// fall back to the caps of the listing when the indexer fails
+				caps, err := c.GetCapsForIndexerCtx(ctx, ind.ID)
+				if err != nil {
+					caps = ind.ParsedCaps()
+				}
+
+				ic := newIndexerCapabilities(ind, caps)
+				ic.Host = c.cfg.Host
+				ic.Err = err
+				ic.client = c
+
+				mu.Lock()
+				matrix = append(matrix, ic)
+				mu.Unlock()
+			}(c, ind)
+		}
+	}
+
+	wg.Wait()
+
+	sort.Slice(matrix, func(a, b int) bool {
+		if matrix[a].Host != matrix[b].Host {
+			return matrix[a].Host < matrix[b].Host
+		}
+
+		return matrix[a].Indexer < matrix[b].Indexer
+	})
+
+	m.mu.Lock()
+	m.caps = matrix
+	m.mu.Unlock()
+
+	return matrix, ctx.Err()
+}
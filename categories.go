@@ -0,0 +1,274 @@
+package jackett
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Standard torznab categories, see https://torznab.github.io/spec-1.3-draft/external/newznab/api.html#predefined-categories
+const (
+	CategoryConsole           = 1000
+	CategoryConsoleNDS        = 1010
+	CategoryConsolePSP        = 1020
+	CategoryConsoleWii        = 1030
+	CategoryConsoleXBox       = 1040
+	CategoryConsoleXBox360    = 1050
+	CategoryConsoleWiiware    = 1060
+	CategoryConsoleXBox360DLC = 1070
+	CategoryConsolePS3        = 1080
+	CategoryConsoleOther      = 1090
+	CategoryConsole3DS        = 1110
+	CategoryConsolePSVita     = 1120
+	CategoryConsoleWiiU       = 1130
+	CategoryConsoleXBoxOne    = 1140
+	CategoryConsolePS4        = 1180
+
+	CategoryMovies        = 2000
+	CategoryMoviesForeign = 2010
+	CategoryMoviesOther   = 2020
+	CategoryMoviesSD      = 2030
+	CategoryMoviesHD      = 2040
+	CategoryMoviesUHD     = 2045
+	CategoryMoviesBluRay  = 2050
+	CategoryMovies3D      = 2060
+	CategoryMoviesDVD     = 2070
+	CategoryMoviesWEBDL   = 2080
+
+	CategoryAudio          = 3000
+	CategoryAudioMP3       = 3010
+	CategoryAudioVideo     = 3020
+	CategoryAudioAudiobook = 3030
+	CategoryAudioLossless  = 3040
+	CategoryAudioOther     = 3050
+	CategoryAudioForeign   = 3060
+
+	CategoryPC              = 4000
+	CategoryPC0day          = 4010
+	CategoryPCISO           = 4020
+	CategoryPCMac           = 4030
+	CategoryPCMobileOther   = 4040
+	CategoryPCGames         = 4050
+	CategoryPCMobileIOS     = 4060
+	CategoryPCMobileAndroid = 4070
+
+	CategoryTV            = 5000
+	CategoryTVWEBDL       = 5010
+	CategoryTVForeign     = 5020
+	CategoryTVSD          = 5030
+	CategoryTVHD          = 5040
+	CategoryTVUHD         = 5045
+	CategoryTVOther       = 5050
+	CategoryTVSport       = 5060
+	CategoryTVAnime       = 5070
+	CategoryTVDocumentary = 5080
+
+	CategoryXXX         = 6000
+	CategoryXXXDVD      = 6010
+	CategoryXXXWMV      = 6020
+	CategoryXXXXviD     = 6030
+	CategoryXXXx264     = 6040
+	CategoryXXXUHD      = 6045
+	CategoryXXXPack     = 6050
+	CategoryXXXImageSet = 6060
+	CategoryXXXOther    = 6070
+	CategoryXXXSD       = 6080
+	CategoryXXXWEBDL    = 6090
+
+	CategoryBooks          = 7000
+	CategoryBooksMags      = 7010
+	CategoryBooksEBook     = 7020
+	CategoryBooksComics    = 7030
+	CategoryBooksTechnical = 7040
+	CategoryBooksOther     = 7050
+	CategoryBooksForeign   = 7060
+
+	CategoryOther       = 8000
+	CategoryOtherMisc   = 8010
+	CategoryOtherHashed = 8020
+)
+
+var (
+	AllConsoleCategories = []int{CategoryConsole, CategoryConsoleNDS, CategoryConsolePSP, CategoryConsoleWii, CategoryConsoleXBox, CategoryConsoleXBox360, CategoryConsoleWiiware, CategoryConsoleXBox360DLC, CategoryConsolePS3, CategoryConsoleOther, CategoryConsole3DS, CategoryConsolePSVita, CategoryConsoleWiiU, CategoryConsoleXBoxOne, CategoryConsolePS4}
+	AllMovieCategories   = []int{CategoryMovies, CategoryMoviesForeign, CategoryMoviesOther, CategoryMoviesSD, CategoryMoviesHD, CategoryMoviesUHD, CategoryMoviesBluRay, CategoryMovies3D, CategoryMoviesDVD, CategoryMoviesWEBDL}
+	AllAudioCategories   = []int{CategoryAudio, CategoryAudioMP3, CategoryAudioVideo, CategoryAudioAudiobook, CategoryAudioLossless, CategoryAudioOther, CategoryAudioForeign}
+	AllPCCategories      = []int{CategoryPC, CategoryPC0day, CategoryPCISO, CategoryPCMac, CategoryPCMobileOther, CategoryPCGames, CategoryPCMobileIOS, CategoryPCMobileAndroid}
+	AllTVCategories      = []int{CategoryTV, CategoryTVWEBDL, CategoryTVForeign, CategoryTVSD, CategoryTVHD, CategoryTVUHD, CategoryTVOther, CategoryTVSport, CategoryTVAnime, CategoryTVDocumentary}
+	AllXXXCategories     = []int{CategoryXXX, CategoryXXXDVD, CategoryXXXWMV, CategoryXXXXviD, CategoryXXXx264, CategoryXXXUHD, CategoryXXXPack, CategoryXXXImageSet, CategoryXXXOther, CategoryXXXSD, CategoryXXXWEBDL}
+	AllBookCategories    = []int{CategoryBooks, CategoryBooksMags, CategoryBooksEBook, CategoryBooksComics, CategoryBooksTechnical, CategoryBooksOther, CategoryBooksForeign}
+	AllOtherCategories   = []int{CategoryOther, CategoryOtherMisc, CategoryOtherHashed}
+)
+
+var categoryNames = map[int]string{
+	CategoryConsole:           "Console",
+	CategoryConsoleNDS:        "Console/NDS",
+	CategoryConsolePSP:        "Console/PSP",
+	CategoryConsoleWii:        "Console/Wii",
+	CategoryConsoleXBox:       "Console/XBox",
+	CategoryConsoleXBox360:    "Console/XBox 360",
+	CategoryConsoleWiiware:    "Console/Wiiware",
+	CategoryConsoleXBox360DLC: "Console/XBox 360 DLC",
+	CategoryConsolePS3:        "Console/PS3",
+	CategoryConsoleOther:      "Console/Other",
+	CategoryConsole3DS:        "Console/3DS",
+	CategoryConsolePSVita:     "Console/PS Vita",
+	CategoryConsoleWiiU:       "Console/WiiU",
+	CategoryConsoleXBoxOne:    "Console/XBox One",
+	CategoryConsolePS4:        "Console/PS4",
+	CategoryMovies:            "Movies",
+	CategoryMoviesForeign:     "Movies/Foreign",
+	CategoryMoviesOther:       "Movies/Other",
+	CategoryMoviesSD:          "Movies/SD",
+	CategoryMoviesHD:          "Movies/HD",
+	CategoryMoviesUHD:         "Movies/UHD",
+	CategoryMoviesBluRay:      "Movies/BluRay",
+	CategoryMovies3D:          "Movies/3D",
+	CategoryMoviesDVD:         "Movies/DVD",
+	CategoryMoviesWEBDL:       "Movies/WEB-DL",
+	CategoryAudio:             "Audio",
+	CategoryAudioMP3:          "Audio/MP3",
+	CategoryAudioVideo:        "Audio/Video",
+	CategoryAudioAudiobook:    "Audio/Audiobook",
+	CategoryAudioLossless:     "Audio/Lossless",
+	CategoryAudioOther:        "Audio/Other",
+	CategoryAudioForeign:      "Audio/Foreign",
+	CategoryPC:                "PC",
+	CategoryPC0day:            "PC/0day",
+	CategoryPCISO:             "PC/ISO",
+	CategoryPCMac:             "PC/Mac",
+	CategoryPCMobileOther:     "PC/Mobile-Other",
+	CategoryPCGames:           "PC/Games",
+	CategoryPCMobileIOS:       "PC/Mobile-iOS",
+	CategoryPCMobileAndroid:   "PC/Mobile-Android",
+	CategoryTV:                "TV",
+	CategoryTVWEBDL:           "TV/WEB-DL",
+	CategoryTVForeign:         "TV/Foreign",
+	CategoryTVSD:              "TV/SD",
+	CategoryTVHD:              "TV/HD",
+	CategoryTVUHD:             "TV/UHD",
+	CategoryTVOther:           "TV/Other",
+	CategoryTVSport:           "TV/Sport",
+	CategoryTVAnime:           "TV/Anime",
+	CategoryTVDocumentary:     "TV/Documentary",
+	CategoryXXX:               "XXX",
+	CategoryXXXDVD:            "XXX/DVD",
+	CategoryXXXWMV:            "XXX/WMV",
+	CategoryXXXXviD:           "XXX/XviD",
+	CategoryXXXx264:           "XXX/x264",
+	CategoryXXXUHD:            "XXX/UHD",
+	CategoryXXXPack:           "XXX/Pack",
+	CategoryXXXImageSet:       "XXX/ImageSet",
+	CategoryXXXOther:          "XXX/Other",
+	CategoryXXXSD:             "XXX/SD",
+	CategoryXXXWEBDL:          "XXX/WEB-DL",
+	CategoryBooks:             "Books",
+	CategoryBooksMags:         "Books/Mags",
+	CategoryBooksEBook:        "Books/EBook",
+	CategoryBooksComics:       "Books/Comics",
+	CategoryBooksTechnical:    "Books/Technical",
+	CategoryBooksOther:        "Books/Other",
+	CategoryBooksForeign:      "Books/Foreign",
+	CategoryOther:             "Other",
+	CategoryOtherMisc:         "Other/Misc",
+	CategoryOtherHashed:       "Other/Hashed",
+}
+
+// CategoryName returns the standard name of a category, e.g. "TV/HD".
+func CategoryName(cat int) string {
+	return categoryNames[cat]
+}
+
+// ParentCategory returns the top level category of a standard category.
+func ParentCategory(cat int) int {
+	return cat / 1000 * 1000
+}
+
+// IsCustomCategory reports whether cat is a tracker specific category, which
+// Jackett numbers from 100000 upwards.
+func IsCustomCategory(cat int) bool {
+	return cat >= 100000
+}
+
+// categorySynonyms adds the words of the standard category names to words
+// trackers commonly use instead.
+var categorySynonyms = map[string]string{
+	"movie":         "movies",
+	"film":          "movies",
+	"films":         "movies",
+	"series":        "tv",
+	"episodes":      "tv",
+	"music":         "audio",
+	"flac":          "lossless",
+	"4k":            "uhd",
+	"2160p":         "uhd",
+	"1080p":         "hd",
+	"720p":          "hd",
+	"bdrip":         "bluray",
+	"web":           "webdl",
+	"webrip":        "webdl",
+	"games":         "pc",
+	"anime":         "tv",
+	"apps":          "pc",
+	"software":      "pc",
+	"ebooks":        "ebook",
+	"magazines":     "mags",
+	"comic":         "comics",
+	"documentaries": "documentary",
+	"sports":        "sport",
+	"adult":         "xxx",
+}
+
+func categoryTokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		// keep "web-dl" as a single word
+		if fields[i] == "web" && i+1 < len(fields) && fields[i+1] == "dl" {
+			tokens = append(tokens, "webdl")
+			i++
+			continue
+		}
+
+		tokens = append(tokens, fields[i])
+	}
+
+	return tokens
+}
+
+// ClosestStandardCategory guesses the standard category matching the name of
+// a tracker specific category, e.g. "TV HD x264" becomes CategoryTVHD. It
+// returns 0 when nothing matches.
+func ClosestStandardCategory(name string) int {
+	words := make(map[string]bool)
+	for _, token := range categoryTokens(name) {
+		words[token] = true
+		if syn, ok := categorySynonyms[token]; ok {
+			words[syn] = true
+		}
+	}
+
+	best, bestScore := 0, 0
+	for cat, catName := range categoryNames {
+		tokens := categoryTokens(catName)
+
+		// the parent has to match before a subcategory counts
+		if !words[tokens[0]] {
+			continue
+		}
+
+		score := 2
+		for _, token := range tokens[1:] {
+			if words[token] {
+				score++
+			}
+		}
+
+		if score > bestScore || (score == bestScore && cat < best) {
+			best, bestScore = cat, score
+		}
+	}
+
+	return best
+}
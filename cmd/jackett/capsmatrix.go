@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var matrixModes = []struct {
+	mode   string
+	header string
+}{
+	{jackett.SearchModeSearch, "SEARCH"},
+	{jackett.SearchModeTV, "TV"},
+	{jackett.SearchModeMovie, "MOVIE"},
+	{jackett.SearchModeMusic, "MUSIC"},
+	{jackett.SearchModeAudio, "AUDIO"},
+	{jackett.SearchModeBook, "BOOK"},
+}
+
+type capsRow struct {
+	Host       string              `json:"host"`
+	Indexer    string              `json:"indexer"`
+	Title      string              `json:"title"`
+	Modes      map[string][]string `json:"modes"`
+	Categories []int               `json:"categories"`
+	Error      string              `json:"error,omitempty"`
+}
+
+func runCapsMatrix(args []string) error {
+	fs := flag.NewFlagSet("capsmatrix", flag.ExitOnError)
+	newClient := clientFlags(fs)
+
+	format := fs.String("format", "table", "output format, table or json")
+	mode := fs.String("mode", "", "only list indexers supporting this search mode, e.g. movie-search")
+	param := fs.String("param", "", "only list indexers supporting this param in -mode, e.g. imdbid")
+
+	fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		return errors.New("unknown format: %v", *format)
+	}
+
+	if *param != "" && *mode == "" {
+		return errors.New("-param requires -mode")
+	}
+
+	matrix, err := jackett.NewMultiClient(newClient()).DiscoverCaps(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *param != "" {
+		matrix = matrix.Supporting(*mode, *param)
+	} else if *mode != "" {
+		var filtered jackett.CapsMatrix
+		for _, ic := range matrix {
+			if ic.SupportsMode(*mode) {
+				filtered = append(filtered, ic)
+			}
+		}
+
+		matrix = filtered
+	}
+
+	if *format == "json" {
+		rows := make([]capsRow, 0, len(matrix))
+		for _, ic := range matrix {
+			row := capsRow{
+				Host:       ic.Host,
+				Indexer:    ic.Indexer,
+				Title:      ic.Title,
+				Modes:      ic.Modes,
+				Categories: ic.Categories,
+			}
+
+			if ic.Err != nil {
+				row.Error = ic.Err.Error()
+			}
+
+			rows = append(rows, row)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if !quiet {
+		fmt.Fprint(tw, "INDEXER")
+		for _, m := range matrixModes {
+			fmt.Fprint(tw, "\t"+m.header)
+		}
+		fmt.Fprintln(tw, "\tCATEGORIES\tERROR")
+	}
+
+	for _, ic := range matrix {
+		fmt.Fprint(tw, ic.Indexer)
+		for _, m := range matrixModes {
+			cell := "-"
+			if ic.SupportsMode(m.mode) {
+				cell = strings.Join(ic.Modes[m.mode], ",")
+			}
+
+			fmt.Fprint(tw, "\t"+cell)
+		}
+
+		errText := ""
+		if ic.Err != nil {
+			errText = ic.Err.Error()
+		}
+
+		fmt.Fprintf(tw, "\t%d\t%v\n", len(ic.Categories), errText)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+type comparison struct {
+	indexer     string
+	results     int
+	bestSeeders int
+	elapsed     time.Duration
+	err         error
+}
+
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	newClient := clientFlags(fs)
+
+	query := fs.String("q", "", "search query")
+	indexers := fs.String("indexers", "", "comma separated indexer ids, defaults to all configured indexers")
+	searchType := fs.String("t", "search", "torznab search type")
+	cat := fs.String("cat", "", "comma separated categories")
+
+	fs.Parse(args)
+
+	if *query == "" {
+		return errors.New("-q is required")
+	}
+
+	c := newClient()
+	ctx := context.Background()
+
+	ids := splitList(*indexers)
+	if len(ids) == 0 {
+		ind, err := c.GetIndexersCtx(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, i := range ind.Indexer {
+			ids = append(ids, i.ID)
+		}
+	}
+
+	opts := map[string]string{
+		"t": *searchType,
+		"q": *query,
+	}
+
+	if *cat != "" {
+		opts["cat"] = *cat
+	}
+
+	results := make([]comparison, len(ids))
+
+	var wg sync.WaitGroup
+	for n, id := range ids {
+		wg.Add(1)
+		go func(n int, id string) {
+			defer wg.Done()
+
+			start := time.Now()
+			rss, err := c.GetTorrentsCtx(ctx, id, opts)
+
+			cmp := comparison{indexer: id, elapsed: time.Since(start), err: err}
+			for _, item := range rss.Channel.Item {
+				cmp.results++
+				if s := item.Seeders(); s > cmp.bestSeeders {
+					cmp.bestSeeders = s
+				}
+			}
+
+			results[n] = cmp
+		}(n, id)
+	}
+
+	wg.Wait()
+
+	// most useful first: most results, then best seeded, then fastest
+	sort.SliceStable(results, func(a, b int) bool {
+		ra, rb := results[a], results[b]
+		if ra.results != rb.results {
+			return ra.results > rb.results
+		}
+
+		if ra.bestSeeders != rb.bestSeeders {
+			return ra.bestSeeders > rb.bestSeeders
+		}
+
+		return ra.elapsed < rb.elapsed
+	})
+
+	if quiet {
+		for _, r := range results {
+			errText := ""
+			if r.err != nil {
+				errText = r.err.Error()
+			}
+
+			fmt.Printf("%v\t%d\t%d\t%d\t%v\n", r.indexer, r.results, r.bestSeeders, r.elapsed.Milliseconds(), errText)
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "INDEXER\tRESULTS\tBEST SEEDERS\tRESPONSE\tERROR")
+		for _, r := range results {
+			errText := ""
+			if r.err != nil {
+				errText = r.err.Error()
+			}
+
+			fmt.Fprintf(tw, "%v\t%d\t%d\t%v\t%v\n", r.indexer, r.results, r.bestSeeders, r.elapsed.Round(time.Millisecond), errText)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return compareOutcome(results)
+}
+
+// compareOutcome returns the first error when every indexer failed, or
+// errNoResults when none found anything.
+func compareOutcome(results []comparison) error {
+	var (
+		total    int
+		firstErr error
+		failed   int
+	)
+
+	for _, r := range results {
+		total += r.results
+		if r.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = r.err
+			}
+		}
+	}
+
+	if len(results) > 0 && failed == len(results) {
+		return firstErr
+	}
+
+	if total == 0 {
+		return errNoResults
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+// Command jackett searches and inspects Jackett and torznab indexers from the
+// command line.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// quiet limits the output to machine-parseable results.
+var quiet bool
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"capsmatrix": {"show the search capabilities of every indexer", runCapsMatrix},
+	"compare":    {"compare indexers for a query", runCompare},
+	"record":     {"record sanitized responses of indexers as test fixtures", runRecord},
+	"serve":      {"watch feeds and serve status and metrics over http", runServe},
+	"tui":        {"search interactively, grabbing or copying results", runTUI},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %v\n", os.Args[1])
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	err := cmd.run(os.Args[2:])
+	if err != nil && !(quiet && errors.Is(err, errNoResults)) {
+		fmt.Fprintf(os.Stderr, "jackett %v: %v\n", os.Args[1], err)
+	}
+
+	os.Exit(exitCode(err))
+}
+
+// Exit codes, so scripts can branch on the outcome.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitNoResults = 3
+	exitAuth      = 4
+	exitNetwork   = 5
+)
+
+var errNoResults = errors.Sentinel("no results")
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	if errors.Is(err, errNoResults) {
+		return exitNoResults
+	}
+
+	if errors.Is(err, jackett.ErrInvalidAPIKey) || errors.Is(err, jackett.ErrMissingAPIKey) {
+		return exitAuth
+	}
+
+	var nerr *jackett.NetworkError
+	if errors.As(err, &nerr) || errors.Is(err, jackett.ErrBackendDown) {
+		return exitNetwork
+	}
+
+	return exitError
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: jackett <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %v\n", name, commands[name].usage)
+	}
+}
+
+// clientFlags registers the connection flags on fs, defaulting to the
+// JACKETT_HOST and JACKETT_API_KEY environment variables.
+func clientFlags(fs *flag.FlagSet) func() *jackett.Client {
+	cfg := clientConfigFlags(fs)
+
+	return func() *jackett.Client {
+		return jackett.NewClient(*cfg)
+	}
+}
+
+// clientConfigFlags is clientFlags for commands adjusting the config before
+// creating the client.
+func clientConfigFlags(fs *flag.FlagSet) *jackett.Config {
+	var cfg jackett.Config
+
+	fs.StringVar(&cfg.Host, "host", envOr("JACKETT_HOST", "http://localhost:9117"), "jackett host")
+	fs.Func("fallback", "comma separated fallback hosts of the same jackett", func(s string) error {
+		cfg.FallbackHosts = splitList(s)
+		return nil
+	})
+	fs.StringVar(&cfg.APIKey, "apikey", os.Getenv("JACKETT_API_KEY"), "jackett api key")
+	fs.BoolVar(&cfg.DirectMode, "direct", false, "host is a standalone torznab server")
+	fs.BoolVar(&cfg.AllowMissingAPIKey, "keyless", false, "allow -direct without an api key")
+	fs.StringVar(&cfg.Mode, "mode", jackett.ModeJackett, "backend host runs, jackett, prowlarr or nzbhydra2")
+	fs.StringVar(&cfg.ProxyURL, "proxy", os.Getenv("JACKETT_PROXY"), "http, https or socks5 proxy url")
+	fs.IntVar(&cfg.Timeout, "timeout", int(jackett.DefaultTimeout/time.Second), "request timeout in seconds")
+	fs.Func("tls-min", "minimum tls version, e.g. 1.2", func(s string) (err error) {
+		cfg.TLSMinVersion, err = jackett.ParseTLSVersion(s)
+		return err
+	})
+	fs.Func("public-host", "host download links of jackett are rewritten to", func(public string) error {
+		cfg.RewriteEnclosure = publicHostRewriter(&cfg.Host, public)
+		return nil
+	})
+	fs.BoolVar(&quiet, "quiet", false, "only print machine-parseable output")
+
+	return &cfg
+}
+
+// publicHostRewriter rewrites download links on the host of the client, read
+// when rewriting since -host may follow -public-host, to the public host.
+func publicHostRewriter(host *string, public string) jackett.EnclosureRewriter {
+	return func(rawURL string) (string, error) {
+		from, err := jackett.NormalizeHost(*host)
+		if err != nil {
+			return "", err
+		}
+
+		return jackett.HostRewriter(from, public)(rawURL)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			out = append(out, field)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// fixtureHost replaces the host of the recorded server in fixtures.
+const fixtureHost = "jackett.example"
+
+var (
+	// query params carrying credentials of jackett or the trackers
+	secretParamRegexp = regexp.MustCompile(`(?i)\b(apikey|jackett_apikey|passkey|torrent_pass|authkey|rsskey|auth|key|uid|token)=[^&"'<\s]+`)
+
+	// passkeys embedded in paths, infohashes are 40 characters and kept
+	passkeyRegexp = regexp.MustCompile(`\b[0-9a-fA-F]{32}\b`)
+)
+
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	cfg := clientConfigFlags(fs)
+
+	dir := fs.String("dir", "testdata", "directory the fixtures are written to")
+	indexers := fs.String("indexer", "", "comma separated indexer ids, required")
+	query := fs.String("q", "", "search query")
+	searchType := fs.String("t", "search", "search type, e.g. tvsearch or movie")
+
+	fs.Parse(args)
+
+	ids := splitList(*indexers)
+	if len(ids) == 0 {
+		return errors.New("-indexer is required")
+	}
+
+	rec, err := newRecorder(cfg.ProxyURL)
+	if err != nil {
+		return err
+	}
+
+	cfg.Transport = rec
+
+	// a retried request would record the last attempt only
+	cfg.DisableRetry = true
+
+	client := jackett.NewClient(*cfg)
+
+	san := sanitizer{host: cfg.Host, apiKey: cfg.APIKey}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return errors.Wrap(err, "could not create fixture directory")
+	}
+
+	ctx := context.Background()
+
+	var failed int
+	for _, id := range ids {
+		_, err := client.GetCapsForIndexerCtx(ctx, id)
+		if werr := rec.write(*dir, id+".caps.xml", san); werr != nil {
+			return werr
+		}
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%v: caps: %v\n", id, err)
+		}
+
+		opts := map[string]string{"t": *searchType}
+		if *query != "" {
+			opts["q"] = *query
+		}
+
+		_, err = client.GetTorrentsCtx(ctx, id, opts)
+		if werr := rec.write(*dir, id+"."+*searchType+".xml", san); werr != nil {
+			return werr
+		}
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%v: %v: %v\n", id, *searchType, err)
+		}
+	}
+
+	if failed > 0 {
+		return errors.New("%d of %d requests failed, their responses were recorded where received", failed, 2*len(ids))
+	}
+
+	return nil
+}
+
+// recorder is a transport keeping the body of the last response.
+type recorder struct {
+	next http.RoundTripper
+
+	mu   sync.Mutex
+	last []byte
+}
+
+func newRecorder(proxy string) (*recorder, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid proxy url")
+		}
+
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	return &recorder{next: transport}, nil
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.last = body
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// write writes the sanitized body of the last response to name in dir, and
+// forgets it. Nothing is written when no response was received.
+func (r *recorder) write(dir, name string, san sanitizer) error {
+	r.mu.Lock()
+	body := r.last
+	r.last = nil
+	r.mu.Unlock()
+
+	if body == nil {
+		return nil
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, san.sanitize(body), 0o644); err != nil {
+		return errors.Wrap(err, "could not write fixture")
+	}
+
+	if !quiet {
+		fmt.Println(path)
+	}
+
+	return nil
+}
+
+// sanitizer removes the api key, tracker credentials and the address of the
+// server from recorded responses.
+type sanitizer struct {
+	host   string
+	apiKey string
+}
+
+func (s sanitizer) sanitize(body []byte) []byte {
+	out := string(body)
+
+	if s.apiKey != "" {
+		out = strings.NewReplacer(s.apiKey, "REDACTED", url.QueryEscape(s.apiKey), "REDACTED").Replace(out)
+	}
+
+	if host, err := jackett.NormalizeHost(s.host); err == nil {
+		if u, err := url.Parse(host); err == nil {
+			out = strings.ReplaceAll(out, u.Host, fixtureHost)
+		}
+	}
+
+	out = secretParamRegexp.ReplaceAllString(out, "${1}=REDACTED")
+	out = passkeyRegexp.ReplaceAllString(out, strings.Repeat("0", 32))
+
+	return []byte(out)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/boltstore"
+	"github.com/kylesanderson/go-jackett/httpapi"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+	"github.com/kylesanderson/go-jackett/torznabserver"
+)
+
+// serveConfig is the config file of the serve command.
+type serveConfig struct {
+	// address of the http endpoint, 127.0.0.1:9118 by default. Listening
+	// on other interfaces requires keys for the api and torznab endpoints.
+	Listen string `json:"listen"`
+
+	Clients map[string]clientConfig `json:"clients"`
+	Feeds   []feedConfig            `json:"feeds"`
+
+	// default poll interval in seconds
+	Interval int `json:"interval"`
+	Workers  int `json:"workers"`
+
+	API apiConfig `json:"api"`
+
+	Torznab torznabConfig `json:"torznab"`
+
+	// bbolt database remembering the delivered items and the feed
+	// watermarks across restarts, kept in memory when empty
+	Store string `json:"store"`
+}
+
+// torznabConfig serves a torznab endpoint under /torznab/api aggregating the
+// clients when set.
+type torznabConfig struct {
+	Clients []string `json:"clients"`
+	Keys    []string `json:"keys"`
+	Title   string   `json:"title"`
+}
+
+// apiConfig enables the REST api under /api/ when Client is set.
+type apiConfig struct {
+	Client string   `json:"client"`
+	Keys   []string `json:"keys"`
+}
+
+type clientConfig struct {
+	Host     string `json:"host"`
+	APIKey   string `json:"apikey"`
+	Direct   bool   `json:"direct"`
+	Keyless  bool   `json:"keyless"`
+	Mode     string `json:"mode"`
+	ProxyURL string `json:"proxy"`
+	Timeout  int    `json:"timeout"`
+
+	// minimum tls version, e.g. "1.2"
+	TLSMinVersion string `json:"tls_min_version"`
+
+	// host download links are rewritten to, e.g. the public address of jackett
+	PublicHost string `json:"public_host"`
+
+	// other addresses of the same jackett, tried when host can't be reached
+	Fallbacks []string `json:"fallback_hosts"`
+
+	// seconds identical searches share one request
+	CoalesceWindow int `json:"coalesce_window"`
+
+	// throttle all requests, and the requests per indexer id
+	RateLimit         *jackett.RateLimit           `json:"rate_limit"`
+	IndexerRateLimits map[string]jackett.RateLimit `json:"indexer_rate_limits"`
+
+	// searches per day and indexer id
+	SearchBudgets map[string]jackett.SearchBudget `json:"search_budgets"`
+}
+
+type feedConfig struct {
+	Name    string            `json:"name"`
+	Client  string            `json:"client"`
+	Indexer string            `json:"indexer"`
+	Params  map[string]string `json:"params"`
+
+	// poll interval in seconds
+	Interval int `json:"interval"`
+
+	// regular expressions the title has to match one of, and none of
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+
+	MinSeeders int `json:"min_seeders"`
+}
+
+// ruleMatch is the action of the rules filtering the feeds, matching items
+// are written and published.
+const ruleMatch jackett.RuleAction = "match"
+
+// feedRule returns the rule filtering the items of the feed.
+func feedRule(fc feedConfig) jackett.Rule {
+	return jackett.Rule{
+		Name:   fc.Name,
+		Action: ruleMatch,
+		Filter: jackett.RuleFilter{
+			Feeds:      []string{fc.Name},
+			Include:    fc.Include,
+			Exclude:    fc.Exclude,
+			MinSeeders: fc.MinSeeders,
+		},
+	}
+}
+
+func loadServeConfig(path string) (serveConfig, error) {
+	var cfg serveConfig
+
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, errors.Wrap(err, "invalid config %v", path)
+	}
+
+	if cfg.Listen == "" {
+		cfg.Listen = "127.0.0.1:9118"
+	}
+
+	if err := checkListen(cfg); err != nil {
+		return cfg, errors.Wrap(err, "invalid config %v", path)
+	}
+
+	return cfg, nil
+}
+
+// checkListen refuses to serve the api and torznab endpoints beyond the
+// loopback interface without keys, they would be open to the network.
+func checkListen(cfg serveConfig) error {
+	host, _, err := net.SplitHostPort(cfg.Listen)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+
+	if cfg.API.Client != "" && len(cfg.API.Keys) == 0 {
+		return errors.New("api: keys are required to listen on %v", cfg.Listen)
+	}
+
+	if len(cfg.Torznab.Clients) > 0 && len(cfg.Torznab.Keys) == 0 {
+		return errors.New("torznab: keys are required to listen on %v", cfg.Listen)
+	}
+
+	return nil
+}
+
+// serveStats counts what the daemon did since it started.
+type serveStats struct {
+	matches  uint64
+	filtered uint64
+	errors   uint64
+}
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := fs.String("config", "jackett.json", "config file")
+	fs.Parse(args)
+
+	cfg, err := loadServeConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	metrics := jackett.NewPrometheusMetrics()
+
+	clients := make(map[string]*jackett.Client, len(cfg.Clients))
+	for name, cc := range cfg.Clients {
+		jcfg := jackett.Config{
+			Host:               cc.Host,
+			APIKey:             cc.APIKey,
+			DirectMode:         cc.Direct,
+			AllowMissingAPIKey: cc.Keyless,
+			Mode:               cc.Mode,
+			ProxyURL:           cc.ProxyURL,
+			Timeout:            cc.Timeout,
+			Metrics:            metrics,
+
+			FallbackHosts:  cc.Fallbacks,
+			CoalesceWindow: cc.CoalesceWindow,
+
+			RateLimit:         cc.RateLimit,
+			IndexerRateLimits: cc.IndexerRateLimits,
+			SearchBudgets:     cc.SearchBudgets,
+		}
+
+		if cc.TLSMinVersion != "" {
+			v, err := jackett.ParseTLSVersion(cc.TLSMinVersion)
+			if err != nil {
+				return errors.Wrap(err, "invalid client %v", name)
+			}
+
+			jcfg.TLSMinVersion = v
+		}
+
+		if err := jcfg.Validate(); err != nil {
+			return errors.Wrap(err, "invalid client %v", name)
+		}
+
+		if cc.PublicHost != "" {
+			host, _ := jackett.NormalizeHost(cc.Host)
+			jcfg.RewriteEnclosure = jackett.HostRewriter(host, cc.PublicHost)
+		}
+
+		clients[name] = jackett.NewClient(jcfg)
+	}
+
+	var (
+		feeds []jackett.WatchFeed
+		rules []jackett.Rule
+		names = make(map[string]bool)
+	)
+
+	for _, fc := range cfg.Feeds {
+		c, ok := clients[fc.Client]
+		if !ok {
+			return errors.New("feed %v: unknown client %q", fc.Name, fc.Client)
+		}
+
+		if fc.Name == "" {
+			fc.Name = fc.Indexer
+		}
+
+		if names[fc.Name] {
+			return errors.New("duplicate feed: %v", fc.Name)
+		}
+
+		names[fc.Name] = true
+		rules = append(rules, feedRule(fc))
+
+		feeds = append(feeds, jackett.WatchFeed{
+			Name:     fc.Name,
+			Client:   c,
+			Indexer:  fc.Indexer,
+			Params:   fc.Params,
+			Interval: time.Duration(fc.Interval) * time.Second,
+		})
+	}
+
+	engine, err := jackett.NewRuleEngine(rules, map[jackett.RuleAction]jackett.RuleHandler{
+		ruleMatch: func(context.Context, jackett.Rule, string, jackett.FeedItem) error { return nil },
+	})
+	if err != nil {
+		return err
+	}
+
+	events := jackett.NewEventWriter(os.Stdout)
+
+	wcfg := jackett.WatcherConfig{
+		Feeds:    feeds,
+		Interval: time.Duration(cfg.Interval) * time.Second,
+		Workers:  cfg.Workers,
+		Rules:    engine,
+	}
+
+	if cfg.Store != "" {
+		store, err := boltstore.Open(cfg.Store, nil)
+		if err != nil {
+			return err
+		}
+
+		defer store.Close()
+
+		wcfg.Seen = store
+		wcfg.Watermarks = store
+	}
+
+	watcher := jackett.NewWatcher(wcfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var stats serveStats
+
+	broker := httpapi.NewBroker()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/schedule", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(watcher.Schedule())
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintf(w, "jackett_watcher_matches_total %d\n", atomic.LoadUint64(&stats.matches))
+		fmt.Fprintf(w, "jackett_watcher_filtered_total %d\n", atomic.LoadUint64(&stats.filtered))
+		fmt.Fprintf(w, "jackett_watcher_errors_total %d\n", atomic.LoadUint64(&stats.errors))
+		fmt.Fprintf(w, "jackett_watcher_dropped_total %d\n", watcher.Dropped())
+		metrics.WriteTo(w)
+	})
+
+	if cfg.API.Client != "" {
+		c, ok := clients[cfg.API.Client]
+		if !ok {
+			return errors.New("api: unknown client %q", cfg.API.Client)
+		}
+
+		all := make([]*jackett.Client, 0, len(clients))
+		for _, c := range clients {
+			all = append(all, c)
+		}
+
+		api := httpapi.New(httpapi.Config{
+			Client:  c,
+			Multi:   jackett.NewMultiClient(all...),
+			APIKeys: cfg.API.Keys,
+			Broker:  broker,
+		})
+
+		mux.Handle("/api/", http.StripPrefix("/api", api))
+	}
+
+	if len(cfg.Torznab.Clients) > 0 {
+		var backends []torznabserver.Backend
+		for _, name := range cfg.Torznab.Clients {
+			c, ok := clients[name]
+			if !ok {
+				return errors.New("torznab: unknown client %q", name)
+			}
+
+			backends = append(backends, torznabserver.Backend{Client: c})
+		}
+
+		mux.Handle("/torznab/api", torznabserver.New(torznabserver.Config{
+			Backends: backends,
+			APIKeys:  cfg.Torznab.Keys,
+			Title:    cfg.Torznab.Title,
+		}))
+	}
+
+	// the watcher is stopped when the server fails, its error is returned
+	// once the events are drained
+	serveErr := make(chan error, 1)
+
+	srv := &http.Server{Addr: cfg.Listen, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- errors.Wrap(err, "http server")
+			stop()
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		srv.Shutdown(shutdown)
+	}()
+
+	go watcher.Run(ctx)
+
+	for ev := range watcher.Events() {
+		if ev.Err != nil {
+			atomic.AddUint64(&stats.errors, 1)
+			events.Error(ev.Feed, ev.Err)
+			broker.Publish(ev)
+			continue
+		}
+
+		if len(ev.Rules) == 0 {
+			atomic.AddUint64(&stats.filtered, 1)
+			continue
+		}
+
+		atomic.AddUint64(&stats.matches, 1)
+		events.Match(ev.Feed, ev.Item)
+		broker.Publish(ev)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// tuiColumns are the columns of the result table, in order. The title takes
+// the width left by the others.
+var tuiColumns = []struct {
+	name  string
+	width int
+	less  func(a, b jackett.FeedItem) bool
+}{
+	{"TITLE", 0, func(a, b jackett.FeedItem) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }},
+	{"INDEXER", 14, func(a, b jackett.FeedItem) bool { return a.Indexer() < b.Indexer() }},
+	{"SIZE", 9, func(a, b jackett.FeedItem) bool { return a.SizeBytes() < b.SizeBytes() }},
+	{"SEED", 6, func(a, b jackett.FeedItem) bool { return a.Seeders() < b.Seeders() }},
+	{"PEER", 6, func(a, b jackett.FeedItem) bool { return a.Peers() < b.Peers() }},
+	{"AGE", 5, func(a, b jackett.FeedItem) bool { return a.PublishDate().After(b.PublishDate()) }},
+}
+
+const tuiHelp = "type to search  ↑/↓ select  enter grab  ctrl+y copy magnet  ctrl+s sort  ctrl+r reverse  esc quit"
+
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	newClient := clientFlags(fs)
+
+	indexer := fs.String("indexer", "all", "indexer to search")
+	searchType := fs.String("t", "search", "torznab search type")
+	cat := fs.String("cat", "", "comma separated categories")
+	dir := fs.String("dir", ".", "directory grabbed torrents are saved to")
+	delay := fs.Duration("delay", 300*time.Millisecond, "pause in typing before searching")
+
+	fs.Parse(args)
+
+	m := &tuiModel{
+		client:  newClient(),
+		indexer: *indexer,
+		opts:    map[string]string{"t": *searchType},
+		dir:     *dir,
+		delay:   *delay,
+		sortCol: 3, // most seeded first
+		desc:    true,
+		status:  tuiHelp,
+	}
+
+	if *cat != "" {
+		m.opts["cat"] = *cat
+	}
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// searchMsg starts the search of seq unless the query changed since.
+type searchMsg struct {
+	seq int
+}
+
+type resultsMsg struct {
+	seq   int
+	items []jackett.FeedItem
+	took  time.Duration
+	err   error
+}
+
+type statusMsg string
+
+// tuiModel searches incrementally as the query is typed, each search
+// cancelling the previous one still running, and shows the results sorted by
+// a column.
+type tuiModel struct {
+	client  *jackett.Client
+	indexer string
+	opts    map[string]string
+	dir     string
+	delay   time.Duration
+
+	// seq numbers the queries, dropping the results of superseded ones
+	query string
+	seq   int
+
+	items   []jackett.FeedItem
+	cursor  int
+	offset  int
+	sortCol int
+	desc    bool
+
+	width, height int
+	status        string
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+
+	case tea.KeyMsg:
+		return m, m.key(msg)
+
+	case searchMsg:
+		if msg.seq != m.seq || strings.TrimSpace(m.query) == "" {
+			return m, nil
+		}
+
+		m.status = fmt.Sprintf("searching %v for %q…", m.indexer, m.query)
+		return m, m.search(msg.seq)
+
+	case resultsMsg:
+		// a newer search superseded it
+		if msg.seq != m.seq {
+			return m, nil
+		}
+
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.items = msg.items
+		m.cursor, m.offset = 0, 0
+		m.sort()
+		m.status = fmt.Sprintf("%d results in %v", len(m.items), msg.took.Round(time.Millisecond))
+
+	case statusMsg:
+		m.status = string(msg)
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) key(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return tea.Quit
+
+	case tea.KeyUp:
+		m.move(-1)
+	case tea.KeyDown:
+		m.move(1)
+	case tea.KeyPgUp:
+		m.move(-m.rows())
+	case tea.KeyPgDown:
+		m.move(m.rows())
+
+	case tea.KeyCtrlS:
+		m.sortCol = (m.sortCol + 1) % len(tuiColumns)
+		m.sort()
+	case tea.KeyCtrlR:
+		m.desc = !m.desc
+		m.sort()
+
+	case tea.KeyEnter:
+		if item, ok := m.selected(); ok {
+			return m.grab(item)
+		}
+	case tea.KeyCtrlY:
+		if item, ok := m.selected(); ok {
+			return copyMagnet(item)
+		}
+
+	case tea.KeyBackspace:
+		if m.query == "" {
+			return nil
+		}
+
+		_, size := utf8.DecodeLastRuneInString(m.query)
+		m.query = m.query[:len(m.query)-size]
+		return m.queryChanged()
+	case tea.KeyCtrlU:
+		m.query = ""
+		return m.queryChanged()
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(msg.Runes)
+		return m.queryChanged()
+	}
+
+	return nil
+}
+
+// queryChanged schedules a search once typing pauses.
+func (m *tuiModel) queryChanged() tea.Cmd {
+	m.seq++
+
+	seq := m.seq
+	return tea.Tick(m.delay, func(time.Time) tea.Msg {
+		return searchMsg{seq: seq}
+	})
+}
+
+func (m *tuiModel) search(seq int) tea.Cmd {
+	opts := make(map[string]string, len(m.opts)+1)
+	for k, v := range m.opts {
+		opts[k] = v
+	}
+
+	opts["q"] = strings.TrimSpace(m.query)
+
+	client, indexer := m.client, m.indexer
+	return func() tea.Msg {
+		start := time.Now()
+		rss, err := client.SearchKeyedCtx(context.Background(), "tui", indexer, opts)
+
+		return resultsMsg{seq: seq, items: rss.Channel.Item, took: time.Since(start), err: err}
+	}
+}
+
+// grab saves the torrent of item to the -dir directory.
+func (m *tuiModel) grab(item jackett.FeedItem) tea.Cmd {
+	client, dir := m.client, m.dir
+
+	return func() tea.Msg {
+		link := item.Enclosure.URL
+		if link == "" || strings.HasPrefix(link, "magnet:") {
+			return statusMsg("no torrent to grab, copy the magnet link with ctrl+y")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), client.Timeout())
+		defer cancel()
+
+		b, err := client.GetEnclosureCtx(ctx, link)
+		if err != nil {
+			return statusMsg("error: " + err.Error())
+		}
+
+		path := filepath.Join(dir, torrentFilename(item.Title))
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			return statusMsg("error: " + err.Error())
+		}
+
+		return statusMsg("saved " + path)
+	}
+}
+
+// copyMagnet copies the magnet link of item to the clipboard of the terminal
+// with OSC 52, which works over ssh too.
+func copyMagnet(item jackett.FeedItem) tea.Cmd {
+	return func() tea.Msg {
+		magnet := item.MagnetURI()
+		if magnet == "" {
+			return statusMsg("no magnet link for " + item.Title)
+		}
+
+		// stderr, not to interleave with the rendering on stdout
+		if _, err := osc52.New(magnet).WriteTo(os.Stderr); err != nil {
+			return statusMsg("error: " + err.Error())
+		}
+
+		return statusMsg("copied magnet link of " + item.Title)
+	}
+}
+
+// torrentFilename returns a file name for the title, replacing the
+// characters file systems don't allow.
+func torrentFilename(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+
+		return r
+	}, strings.TrimSpace(title))
+
+	if name == "" {
+		name = "download"
+	}
+
+	return name + ".torrent"
+}
+
+func (m *tuiModel) selected() (jackett.FeedItem, bool) {
+	if m.cursor >= len(m.items) {
+		return jackett.FeedItem{}, false
+	}
+
+	return m.items[m.cursor], true
+}
+
+func (m *tuiModel) sort() {
+	less := tuiColumns[m.sortCol].less
+	sort.SliceStable(m.items, func(a, b int) bool {
+		if m.desc {
+			return less(m.items[b], m.items[a])
+		}
+
+		return less(m.items[a], m.items[b])
+	})
+}
+
+func (m *tuiModel) move(n int) {
+	m.cursor += n
+	if m.cursor >= len(m.items) {
+		m.cursor = len(m.items) - 1
+	}
+
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	m.scroll()
+}
+
+// scroll keeps the cursor in view.
+func (m *tuiModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+
+	if rows := m.rows(); m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+// rows returns the number of result rows that fit the screen below the
+// query and the header and above the status line.
+func (m *tuiModel) rows() int {
+	if rows := m.height - 4; rows > 0 {
+		return rows
+	}
+
+	return 1
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "search %v: %v█\n\n", m.indexer, m.query)
+
+	titleWidth := m.width - 2
+	for _, col := range tuiColumns[1:] {
+		titleWidth -= col.width + 1
+	}
+
+	if titleWidth < 10 {
+		titleWidth = 10
+	}
+
+	header := make([]string, len(tuiColumns))
+	for n, col := range tuiColumns {
+		name := col.name
+		if n == m.sortCol {
+			name += map[bool]string{true: "↓", false: "↑"}[m.desc]
+		}
+
+		header[n] = name
+	}
+
+	b.WriteString("  " + m.row(header, titleWidth) + "\n")
+
+	end := m.offset + m.rows()
+	if end > len(m.items) {
+		end = len(m.items)
+	}
+
+	for n := m.offset; n < end; n++ {
+		item := m.items[n]
+
+		marker := "  "
+		if n == m.cursor {
+			marker = "> "
+		}
+
+		b.WriteString(marker + m.row([]string{
+			item.Title,
+			item.Indexer(),
+			formatSize(item.SizeBytes()),
+			fmt.Sprint(item.Seeders()),
+			fmt.Sprint(item.Peers()),
+			formatAge(time.Since(item.PublishDate())),
+		}, titleWidth) + "\n")
+	}
+
+	for n := end - m.offset; n < m.rows(); n++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fit(m.status, m.width))
+	return b.String()
+}
+
+// row pads the cells to the widths of their columns.
+func (m *tuiModel) row(cells []string, titleWidth int) string {
+	out := make([]string, len(cells))
+	for n, cell := range cells {
+		width := tuiColumns[n].width
+		if width == 0 {
+			width = titleWidth
+		}
+
+		out[n] = fit(cell, width)
+	}
+
+	return strings.Join(out, " ")
+}
+
+// fit truncates or pads s to width runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	if n := utf8.RuneCountInString(s); n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+
+	return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+}
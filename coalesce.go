@@ -0,0 +1,100 @@
+package jackett
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// coalescedSearches shares one request among identical searches started
+// while it runs or within the window after it finished.
+type coalescedSearches struct {
+	mu      sync.Mutex
+	flights map[string]*searchFlight
+}
+
+type searchFlight struct {
+	done     chan struct{}
+	finished time.Time
+
+	rss Rss
+	err error
+}
+
+// do runs search under key, or waits for the flight already started under it.
+// The flight runs detached from the context of the search that started it,
+// bounded by timeout, so cancelling that search doesn't fail the others
+// waiting for it. The values of the context, e.g. a tracer, are kept.
+func (cs *coalescedSearches) do(ctx context.Context, key string, clock Clock, window, timeout time.Duration, search func(context.Context) (Rss, error)) (Rss, error) {
+	now := clock.Now()
+
+	cs.mu.Lock()
+
+	if cs.flights == nil {
+		cs.flights = make(map[string]*searchFlight)
+	}
+
+	for k, f := range cs.flights {
+		if !f.finished.IsZero() && now.Sub(f.finished) > window {
+			delete(cs.flights, k)
+		}
+	}
+
+	f, ok := cs.flights[key]
+	if !ok {
+		f = &searchFlight{done: make(chan struct{})}
+		cs.flights[key] = f
+
+		go cs.fly(detachedContext{ctx}, key, f, clock, timeout, search)
+	}
+
+	cs.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return copyRss(f.rss), f.err
+	case <-ctx.Done():
+		return Rss{}, errors.Wrap(ctx.Err(), "waiting for coalesced search")
+	}
+}
+
+func (cs *coalescedSearches) fly(ctx context.Context, key string, f *searchFlight, clock Clock, timeout time.Duration, search func(context.Context) (Rss, error)) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	f.rss, f.err = search(ctx)
+
+	cs.mu.Lock()
+	if f.err != nil {
+		// failures are shared with the waiting searches only
+		delete(cs.flights, key)
+	} else {
+		f.finished = clock.Now()
+	}
+	cs.mu.Unlock()
+
+	close(f.done)
+}
+
+// detachedContext keeps the values of its parent but is never done.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
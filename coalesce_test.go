@@ -0,0 +1,75 @@
+package jackett_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+// TestCoalescedSearchOutlivesLeader cancels the search that started a
+// coalesced request and expects the searches waiting for it to succeed.
+func TestCoalescedSearchOutlivesLeader(t *testing.T) {
+	srv := jackettest.NewServer("apikey")
+	defer srv.Close()
+
+	srv.AddIndexer(jackettest.NewIndexer("tracker", "Tracker", 2000),
+		jackettest.Item("Ubuntu 24.04", 2000, 10))
+	srv.SetLatency("tracker", 200*time.Millisecond)
+
+	client := jackett.NewClient(jackett.Config{
+		Host:           srv.URL,
+		APIKey:         "apikey",
+		CoalesceWindow: 60,
+		DisableRetry:   true,
+	})
+
+	opts := map[string]string{"t": "search", "q": "ubuntu"}
+
+	leader, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, err := client.GetTorrentsCtx(leader, "tracker", opts)
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+
+	// wait for the leader to start the request
+	require.Eventually(t, func() bool { return len(srv.Requests()) == 1 }, time.Second, time.Millisecond)
+
+	results := make(chan error, 3)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rss, err := client.GetTorrentsCtx(context.Background(), "tracker", opts)
+			if err == nil && len(rss.Channel.Item) != 1 {
+				err = assert.AnError
+			}
+
+			results <- err
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	wg.Wait()
+	close(results)
+
+	for err := range results {
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, srv.Requests(), 1)
+}
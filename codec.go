@@ -0,0 +1,107 @@
+package jackett
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Codec serializes the values the package persists, e.g. cached results, so
+// existing storage formats and embedded databases can be reused.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSONCodec Codec = jsonCodec{}
+	GobCodec  Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// CachedResult is a persisted entry of the result cache.
+type CachedResult struct {
+	Key     string
+	Rss     Rss
+	Expires time.Time
+}
+
+// cacheEnvelope is the layout written by SaveCache, the results tagged with
+// CacheFormatVersion.
+type cacheEnvelope struct {
+	Version int            `json:"version"`
+	Data    []CachedResult `json:"data"`
+}
+
+// SaveCache writes the unexpired cached results to w, so the cache survives
+// restarts. A nil codec defaults to JSONCodec.
+func (c *Client) SaveCache(w io.Writer, codec Codec) error {
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	data, err := codec.Marshal(cacheEnvelope{
+		Version: CacheFormatVersion,
+		Data:    c.cache.snapshot(c.clock.Now()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not encode cache")
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// LoadCache adds the results written by SaveCache to the cache, skipping the
+// ones that expired since. It fails with ErrUnsupportedVersion for caches
+// written in a format version it doesn't know.
+func (c *Client) LoadCache(r io.Reader, codec Codec) error {
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "could not read cache")
+	}
+
+	var env cacheEnvelope
+	if err := codec.Unmarshal(data, &env); err != nil {
+		return errors.Wrap(err, "could not decode cache")
+	}
+
+	if err := checkVersion("cache", env.Version, CacheFormatVersion); err != nil {
+		return err
+	}
+
+	c.cache.restore(env.Data, c.clock.Now())
+	return nil
+}
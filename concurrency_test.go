@@ -0,0 +1,242 @@
+package jackett_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+const testCaps = `<?xml version="1.0" encoding="UTF-8"?>
+<caps>
+  <server title="Jackett" />
+  <limits default="100" max="100" />
+  <searching>
+    <search available="yes" supportedParams="q" />
+    <tv-search available="yes" supportedParams="q,season,ep,imdbid" />
+  </searching>
+  <categories>
+    <category id="2000" name="Movies" />
+    <category id="5000" name="TV" />
+  </categories>
+</caps>`
+
+const testIndexers = `<?xml version="1.0" encoding="UTF-8"?>
+<indexers>
+  <indexer id="tracker" configured="true">
+    <title>Tracker</title>
+    <type>public</type>
+    <caps>
+      <searching>
+        <search available="yes" supportedParams="q" />
+      </searching>
+      <categories>
+        <category id="2000" name="Movies" />
+      </categories>
+    </caps>
+  </indexer>
+</indexers>`
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+  <channel>
+    <title>test</title>
+    <item>
+      <title>%s</title>
+      <guid>https://tracker.invalid/details/%s</guid>
+      <link>%s/dl/%s.torrent</link>
+      <enclosure url="%s/dl/%s.torrent" length="1024" type="application/x-bittorrent" />
+      <torznab:attr name="seeders" value="10" />
+      <torznab:attr name="peers" value="12" />
+    </item>
+  </channel>
+</rss>`
+
+// newTestServer serves caps, an indexer listing, search results echoing the
+// query and downloads, setting a cookie on every response so the cookie jar
+// is written to.
+func newTestServer(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "Jackett", Value: fmt.Sprint(time.Now().UnixNano()), Path: "/"})
+
+		if strings.HasPrefix(r.URL.Path, "/dl/") {
+			w.Write(bytes.Repeat([]byte("d"), 1024))
+			return
+		}
+
+		q := r.URL.Query()
+		switch q.Get("t") {
+		case "caps":
+			w.Write([]byte(testCaps))
+			return
+		case "indexers":
+			w.Write([]byte(testIndexers))
+			return
+		}
+
+		title := q.Get("q")
+		fmt.Fprintf(w, testFeed, title, title, srv.URL, title, srv.URL, title)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+type countingLogger struct {
+	n int64
+}
+
+func (l *countingLogger) Debug(string, ...interface{}) { atomic.AddInt64(&l.n, 1) }
+func (l *countingLogger) Info(string, ...interface{})  { atomic.AddInt64(&l.n, 1) }
+func (l *countingLogger) Warn(string, ...interface{})  { atomic.AddInt64(&l.n, 1) }
+func (l *countingLogger) Error(string, ...interface{}) { atomic.AddInt64(&l.n, 1) }
+
+// TestClientConcurrentUse shares one client between goroutines searching,
+// downloading and refreshing caps while others swap its logger, timeout and
+// rate limiter and persist its cache. Run with -race.
+func TestClientConcurrentUse(t *testing.T) {
+	srv := newTestServer(t)
+
+	client := jackett.NewClient(jackett.Config{
+		Host:           srv.URL,
+		APIKey:         "apikey",
+		CacheTTL:       60,
+		CoalesceWindow: 1,
+	})
+
+	ctx := context.Background()
+
+	// shared by every search, the client must not modify it
+	opts := map[string]string{"t": "search", "cat": "2000"}
+
+	const (
+		workers    = 8
+		iterations = 25
+	)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*iterations*3)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				params := make(map[string]string, len(opts)+1)
+				for k, v := range opts {
+					params[k] = v
+				}
+
+				// a few distinct queries so cached, coalesced and fresh
+				// searches interleave
+				params["q"] = fmt.Sprintf("release%d", (w+i)%4)
+
+				rss, err := client.GetTorrentsCtx(ctx, "all", params)
+				if err != nil {
+					errs <- err
+					continue
+				}
+
+				if len(rss.Channel.Item) != 1 {
+					errs <- fmt.Errorf("got %d items", len(rss.Channel.Item))
+					continue
+				}
+
+				if _, err := client.GetTorrentsCtx(ctx, "all", opts); err != nil {
+					errs <- err
+				}
+
+				b, err := client.GetEnclosureCtx(ctx, rss.Channel.Item[0].Enclosure.URL)
+				if err != nil {
+					errs <- err
+				} else if len(b) != 1024 {
+					errs <- fmt.Errorf("got %d bytes", len(b))
+				}
+
+				if _, err := client.GetCapsForIndexerCtx(ctx, "all"); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	for w := 0; w < 2; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				client.SetLogger(&countingLogger{})
+				client.SetTimeout(time.Duration(10+i) * time.Second)
+				client.SetRateLimiter(nil)
+
+				var buf bytes.Buffer
+				if err := client.SaveCache(&buf, nil); err != nil {
+					errs <- err
+				}
+
+				if err := client.LoadCache(&buf, nil); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]string{"t": "search", "cat": "2000"}, opts)
+}
+
+// TestMultiClientConcurrentSearch runs aggregated searches while the indexers
+// are refreshed.
+func TestMultiClientConcurrentSearch(t *testing.T) {
+	srv := newTestServer(t)
+
+	client := jackett.NewClient(jackett.Config{Host: srv.URL, APIKey: "apikey"})
+	multi := jackett.NewMultiClient(client)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 10; i++ {
+				_, err := multi.SearchCtx(ctx, map[string]string{"t": "search", "q": "release"})
+				assert.NoError(t, err)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 10; i++ {
+				assert.NoError(t, multi.RefreshIndexers(ctx))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,115 @@
+package jackett
+
+import (
+	"sync"
+	"time"
+)
+
+type CooldownScope string
+
+const (
+	// the rule as a whole
+	CooldownRule CooldownScope = "rule"
+
+	// each series, as parsed from the title, items without a season or
+	// episode tag are not limited
+	CooldownSeries CooldownScope = "series"
+
+	// each indexer the items come from
+	CooldownIndexer CooldownScope = "indexer"
+)
+
+// Cooldown limits how often the action of a rule runs, e.g.
+// {Scope: CooldownSeries, Window: 6 * 3600} for at most one grab per series
+// every 6 hours, or {Scope: CooldownIndexer, Window: 3600, Max: 5} for 5 grabs
+// an hour per indexer. Series and indexer cooldowns are shared by the rules
+// with the same action.
+type Cooldown struct {
+	Scope CooldownScope `json:"scope"`
+
+	// seconds
+	Window int `json:"window"`
+
+	// actions allowed per window, defaults to 1
+	Max int `json:"max,omitempty"`
+}
+
+func (cd Cooldown) window() time.Duration {
+	return time.Duration(cd.Window) * time.Second
+}
+
+func (cd Cooldown) max() int {
+	if cd.Max <= 0 {
+		return 1
+	}
+
+	return cd.Max
+}
+
+// key returns the key the actions are counted under, false when the
+// cooldown doesn't apply to the item.
+func (cd Cooldown) key(rule Rule, item FeedItem) (string, bool) {
+	switch cd.Scope {
+	case CooldownRule:
+		return "rule/" + rule.Name, true
+	case CooldownSeries:
+		ep, ok := ParseEpisode(item.Title)
+		return string(rule.Action) + "/series/" + ep.Series, ok
+	case CooldownIndexer:
+		return string(rule.Action) + "/indexer/" + item.Indexer(), true
+	}
+
+	return "", false
+}
+
+// CooldownStore records the actions of rules with cooldowns, so the limits
+// hold across restarts. The rule engine keeps them in memory by default.
+type CooldownStore interface {
+	// CountActions returns the number of actions recorded for key at or
+	// after since.
+	CountActions(key string, since time.Time) (int, error)
+	RecordAction(key string, at time.Time) error
+}
+
+// memoryCooldowns keeps the actions of the last retain.
+type memoryCooldowns struct {
+	retain time.Duration
+
+	mu      sync.Mutex
+	actions map[string][]time.Time
+}
+
+func newMemoryCooldowns(retain time.Duration) *memoryCooldowns {
+	return &memoryCooldowns{retain: retain, actions: make(map[string][]time.Time)}
+}
+
+func (m *memoryCooldowns) CountActions(key string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, at := range m.actions[key] {
+		if !at.Before(since) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *memoryCooldowns) RecordAction(key string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := at.Add(-m.retain)
+
+	kept := m.actions[key][:0]
+	for _, t := range m.actions[key] {
+		if !t.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+
+	m.actions[key] = append(kept, at)
+	return nil
+}
@@ -0,0 +1,176 @@
+package jackett
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+func cooldownItem(title, indexer string) FeedItem {
+	return FeedItem{Title: title, Jackettindexer: JackettIndexer{ID: indexer}}
+}
+
+// failingGrab fails the grabs of titles containing "fail".
+func failingGrab(_ context.Context, _ Rule, _ string, item FeedItem) error {
+	if strings.Contains(item.Title, "fail") {
+		return errors.New("grab failed")
+	}
+
+	return nil
+}
+
+func TestCooldowns(t *testing.T) {
+	type step struct {
+		title   string
+		indexer string
+
+		// seconds the clock advances before the evaluation
+		advance int
+
+		ran bool
+	}
+
+	tests := []struct {
+		name      string
+		cooldowns []Cooldown
+		steps     []step
+	}{
+		{
+			name:      "rule",
+			cooldowns: []Cooldown{{Scope: CooldownRule, Window: 60}},
+			steps: []step{
+				{title: "Show S01E01", ran: true},
+				{title: "Other S01E01"},
+				{title: "Other S01E01", advance: 59},
+				{title: "Other S01E01", advance: 2, ran: true},
+			},
+		},
+		{
+			name:      "series",
+			cooldowns: []Cooldown{{Scope: CooldownSeries, Window: 3600}},
+			steps: []step{
+				{title: "Show S01E01 1080p", ran: true},
+				{title: "Show S01E02 1080p"},
+				{title: "Other S01E01 1080p", ran: true},
+				{title: "Movie 2024 1080p", ran: true},
+				{title: "Movie 2024 2160p", ran: true},
+				{title: "Show S01E03 1080p", advance: 3601, ran: true},
+			},
+		},
+		{
+			name:      "indexer",
+			cooldowns: []Cooldown{{Scope: CooldownIndexer, Window: 3600, Max: 2}},
+			steps: []step{
+				{title: "Show S01E01", indexer: "a", ran: true},
+				{title: "Show S01E02", indexer: "a", ran: true},
+				{title: "Show S01E03", indexer: "a"},
+				{title: "Show S01E03", indexer: "b", ran: true},
+			},
+		},
+		{
+			name:      "all of several",
+			cooldowns: []Cooldown{{Scope: CooldownSeries, Window: 3600}, {Scope: CooldownIndexer, Window: 3600}},
+			steps: []step{
+				{title: "Show S01E01", indexer: "a", ran: true},
+				{title: "Other S01E01", indexer: "a"},
+				{title: "Show S01E02", indexer: "b"},
+				{title: "Other S01E01", indexer: "b", ran: true},
+			},
+		},
+		{
+			name:      "failed actions don't count",
+			cooldowns: []Cooldown{{Scope: CooldownRule, Window: 60}},
+			steps: []step{
+				{title: "fail S01E01", ran: true},
+				{title: "Show S01E01", ran: true},
+				{title: "Show S01E02"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewRuleEngine([]Rule{{Name: "grab", Action: RuleGrab, Cooldowns: tt.cooldowns}},
+				map[RuleAction]RuleHandler{RuleGrab: failingGrab})
+			require.NoError(t, err)
+
+			clock := newFakeClock()
+			e.clock = clock
+
+			for n, s := range tt.steps {
+				clock.Advance(time.Duration(s.advance) * time.Second)
+
+				ran, _ := e.Evaluate(context.Background(), "feed", cooldownItem(s.title, s.indexer))
+				assert.Equal(t, s.ran, len(ran) == 1, "step %d: %v", n, s.title)
+			}
+		})
+	}
+}
+
+// TestCooldownActionUnlocked runs an action that blocks and expects other
+// items to be evaluated meanwhile, the blocked one holding its place in the
+// cooldown until it fails.
+func TestCooldownActionUnlocked(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+
+	grab := func(ctx context.Context, rule Rule, feed string, item FeedItem) error {
+		if strings.Contains(item.Title, "block") {
+			close(started)
+			<-release
+		}
+
+		return failingGrab(ctx, rule, feed, item)
+	}
+
+	e, err := NewRuleEngine([]Rule{{
+		Name:      "grab",
+		Action:    RuleGrab,
+		Cooldowns: []Cooldown{{Scope: CooldownSeries, Window: 3600}},
+	}}, map[RuleAction]RuleHandler{RuleGrab: grab})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Evaluate(ctx, "feed", cooldownItem("Show S01E01 block fail", ""))
+		done <- err
+	}()
+
+	<-started
+
+	evaluated := make(chan []string)
+	go func() {
+		ran, _ := e.Evaluate(ctx, "feed", cooldownItem("Other S01E01", ""))
+		evaluated <- ran
+	}()
+
+	select {
+	case ran := <-evaluated:
+		assert.Len(t, ran, 1)
+	case <-time.After(time.Second):
+		t.Fatal("evaluation waited for the action of another item")
+	}
+
+	// reserved by the running action
+	ran, err := e.Evaluate(ctx, "feed", cooldownItem("Show S01E02", ""))
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	close(release)
+	assert.Error(t, <-done)
+
+	// the failed action released its reservation
+	ran, err = e.Evaluate(ctx, "feed", cooldownItem("Show S01E02", ""))
+	require.NoError(t, err)
+	assert.Len(t, ran, 1)
+
+	assert.Equal(t, uint64(1), e.Stats()["grab"].Failed)
+	assert.Equal(t, uint64(1), e.Stats()["grab"].Suppressed)
+}
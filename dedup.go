@@ -0,0 +1,153 @@
+package jackett
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var DefaultDedupWindow = 7 * 24 * time.Hour
+
+type DedupPolicy int
+
+const (
+	// DedupFirst delivers the first release of an episode and drops the rest.
+	DedupFirst DedupPolicy = iota
+
+	// DedupBest holds the releases of an episode for EpisodeDedup.Delay and
+	// delivers the best of them.
+	DedupBest
+)
+
+// EpisodeDedup suppresses different releases of the same episode, e.g. when
+// several trackers announce the same airing, across all feeds of a watcher.
+// Titles without a season or episode tag are not deduplicated. The episodes
+// are kept in memory, releases held by DedupBest when the watcher stops are
+// not delivered.
+type EpisodeDedup struct {
+	Policy DedupPolicy
+
+	// how long releases are collected before the best is delivered
+	Delay time.Duration
+
+	// how long a delivered episode suppresses later releases, defaults to
+	// DefaultDedupWindow
+	Window time.Duration
+
+	// reports whether a is better than b, defaults to BetterRelease
+	Better func(a, b FeedItem) bool
+}
+
+// heldRelease is the best release of an episode so far.
+type heldRelease struct {
+	feed     string
+	item     FeedItem
+	deadline time.Time
+}
+
+type dedupState struct {
+	cfg EpisodeDedup
+
+	mu        sync.Mutex
+	delivered map[string]time.Time
+	held      map[string]*heldRelease
+}
+
+func newDedupState(cfg EpisodeDedup) *dedupState {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultDedupWindow
+	}
+
+	if cfg.Better == nil {
+		cfg.Better = BetterRelease
+	}
+
+	return &dedupState{
+		cfg:       cfg,
+		delivered: make(map[string]time.Time),
+		held:      make(map[string]*heldRelease),
+	}
+}
+
+// offer reports whether the item is to be delivered now. Items of episodes
+// delivered within the window are dropped, with DedupBest the others are
+// held until released by due.
+func (d *dedupState) offer(feed string, item FeedItem, now time.Time) bool {
+	ep, ok := ParseEpisode(item.Title)
+	if !ok {
+		return true
+	}
+
+	key := ep.Key()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, at := range d.delivered {
+		if now.Sub(at) >= d.cfg.Window {
+			delete(d.delivered, k)
+		}
+	}
+
+	if _, ok := d.delivered[key]; ok {
+		return false
+	}
+
+	if d.cfg.Policy == DedupFirst {
+		d.delivered[key] = now
+		return true
+	}
+
+	if h, ok := d.held[key]; ok {
+		if d.cfg.Better(item, h.item) {
+			h.feed, h.item = feed, item
+		}
+
+		return false
+	}
+
+	d.held[key] = &heldRelease{feed: feed, item: item, deadline: now.Add(d.cfg.Delay)}
+	return false
+}
+
+// due removes and returns the held releases whose delay passed.
+func (d *dedupState) due(now time.Time) []heldRelease {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []heldRelease
+	for key, h := range d.held {
+		if h.deadline.After(now) {
+			continue
+		}
+
+		out = append(out, *h)
+		delete(d.held, key)
+		d.delivered[key] = now
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].deadline.Before(out[j].deadline)
+	})
+
+	return out
+}
+
+// next returns the earliest deadline of the held releases.
+func (d *dedupState) next() (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var (
+		next time.Time
+		ok   bool
+	)
+
+	for _, h := range d.held {
+		if !ok || h.deadline.Before(next) {
+			next, ok = h.deadline, true
+		}
+	}
+
+	return next, ok
+}
@@ -0,0 +1,236 @@
+package jackett
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	bbcodeImgRegexp  = regexp.MustCompile(`(?is)\[img(?:=[^\]]*)?\].*?\[/img\]`)
+	bbcodeURLRegexp  = regexp.MustCompile(`(?is)\[url=([^\]]+)\](.*?)\[/url\]`)
+	bbcodeLinkRegexp = regexp.MustCompile(`(?is)\[url\](.*?)\[/url\]`)
+	bbcodeTagRegexp  = regexp.MustCompile(`(?i)\[/?([a-z*]+)(?:=[^\]]*)?\]`)
+	blankLineRegexp  = regexp.MustCompile(`\n\s*\n+`)
+	spaceRegexp      = regexp.MustCompile(`[ \t\r\f]+`)
+)
+
+// bbcodeTags maps the BBCode tags with an HTML equivalent.
+var bbcodeTags = map[string]string{
+	"b":     "b",
+	"i":     "i",
+	"u":     "u",
+	"s":     "s",
+	"quote": "blockquote",
+	"code":  "code",
+	"list":  "ul",
+	"*":     "li",
+}
+
+// allowedTags are kept by DescriptionHTML, all others are dropped while
+// their text is kept.
+var allowedTags = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Strong: true, atom.I: true, atom.Em: true,
+	atom.U: true, atom.S: true, atom.Br: true, atom.P: true, atom.Div: true,
+	atom.Span: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.Blockquote: true, atom.Code: true, atom.Pre: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+}
+
+// droppedTags are removed together with their content.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Noscript: true, atom.Template: true, atom.Head: true,
+}
+
+// voidTags have no end tag.
+var voidTags = map[atom.Atom]bool{
+	atom.Br: true,
+}
+
+// blockTags end a line in plain text.
+var blockTags = map[atom.Atom]bool{
+	atom.Br: true, atom.P: true, atom.Div: true, atom.Li: true, atom.Tr: true,
+	atom.Blockquote: true, atom.Pre: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// bbcodeToHTML converts the common BBCode tags to HTML and strips the rest.
+func bbcodeToHTML(s string) string {
+	s = bbcodeImgRegexp.ReplaceAllString(s, "")
+	s = bbcodeURLRegexp.ReplaceAllString(s, `<a href="$1">$2</a>`)
+	s = bbcodeLinkRegexp.ReplaceAllString(s, `<a href="$1">$1</a>`)
+
+	return bbcodeTagRegexp.ReplaceAllStringFunc(s, func(tag string) string {
+		m := bbcodeTagRegexp.FindStringSubmatch(tag)
+		name, ok := bbcodeTags[strings.ToLower(m[1])]
+		if !ok {
+			return ""
+		}
+
+		if strings.HasPrefix(tag, "[/") {
+			return "</" + name + ">"
+		}
+
+		return "<" + name + ">"
+	})
+}
+
+// DescriptionText returns the description as plain text, without HTML or
+// BBCode markup and with entities decoded.
+func (i FeedItem) DescriptionText() string {
+	var buf strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(bbcodeToHTML(i.Description)))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			text := spaceRegexp.ReplaceAllString(buf.String(), " ")
+			text = blankLineRegexp.ReplaceAllString(text, "\n\n")
+
+			lines := strings.Split(text, "\n")
+			for n := range lines {
+				lines[n] = strings.TrimSpace(lines[n])
+			}
+
+			return strings.TrimSpace(strings.Join(lines, "\n"))
+
+		case html.TextToken:
+			if skip == 0 {
+				buf.Write(z.Text())
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if droppedTags[tok.DataAtom] && tok.Type == html.StartTagToken {
+				skip++
+			}
+
+			if blockTags[tok.DataAtom] {
+				buf.WriteByte('\n')
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			if droppedTags[tok.DataAtom] && skip > 0 {
+				skip--
+			}
+
+			if blockTags[tok.DataAtom] {
+				buf.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// DescriptionHTML returns the description as HTML that is safe to embed:
+// BBCode is converted, only basic formatting tags are kept, and links are
+// limited to http, https and magnet. Tags are balanced, end tags without a
+// matching start tag are dropped and the tags left open are closed.
+func (i FeedItem) DescriptionHTML() string {
+	var (
+		buf  bytes.Buffer
+		open []atom.Atom
+	)
+
+	z := html.NewTokenizer(strings.NewReader(bbcodeToHTML(i.Description)))
+	skip := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+
+			for n := len(open) - 1; n >= 0; n-- {
+				buf.WriteString("</" + open[n].String() + ">")
+			}
+
+			return buf.String()
+		}
+
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				buf.WriteString(html.EscapeString(tok.Data))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[tok.DataAtom] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+
+				continue
+			}
+
+			if skip > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+
+			tok.Attr = sanitizeAttrs(tok)
+			buf.WriteString(tok.String())
+
+			if tt == html.StartTagToken && !voidTags[tok.DataAtom] {
+				open = append(open, tok.DataAtom)
+			}
+
+		case html.EndTagToken:
+			if droppedTags[tok.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+
+				continue
+			}
+
+			if skip > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+
+			// close the tags opened since its start tag along with it
+			for n := len(open) - 1; n >= 0; n-- {
+				if open[n] != tok.DataAtom {
+					continue
+				}
+
+				for len(open) > n {
+					buf.WriteString("</" + open[len(open)-1].String() + ">")
+					open = open[:len(open)-1]
+				}
+
+				break
+			}
+		}
+	}
+}
+
+func sanitizeAttrs(tok html.Token) []html.Attribute {
+	if tok.DataAtom != atom.A {
+		return nil
+	}
+
+	for _, attr := range tok.Attr {
+		if attr.Key != "href" {
+			continue
+		}
+
+		href := strings.TrimSpace(attr.Val)
+		lower := strings.ToLower(href)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "magnet:") {
+			return []html.Attribute{
+				{Key: "href", Val: href},
+				{Key: "rel", Val: "nofollow noopener noreferrer"},
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package jackett_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+func TestDescriptionHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name:        "formatting",
+			description: `<p>A <b>bold</b> <em>release</em><br>of 2024</p>`,
+			want:        `<p>A <b>bold</b> <em>release</em><br>of 2024</p>`,
+		},
+		{
+			name:        "bbcode",
+			description: `[b]Bold[/b] [img]https://tracker.invalid/cover.jpg[/img][url=https://tracker.invalid/]site[/url]`,
+			want:        `<b>Bold</b> <a href="https://tracker.invalid/" rel="nofollow noopener noreferrer">site</a>`,
+		},
+		{
+			name:        "escaped text",
+			description: `<b>&lt;script&gt;alert(1)&lt;/script&gt;</b>`,
+			want:        `<b>&lt;script&gt;alert(1)&lt;/script&gt;</b>`,
+		},
+		{
+			name:        "script",
+			description: `<b>safe</b><script>alert(1)</script><SCRIPT src="https://evil.invalid/x.js"></SCRIPT>`,
+			want:        `<b>safe</b>`,
+		},
+		{
+			name:        "style and iframe",
+			description: `<style>body{display:none}</style><iframe src="https://evil.invalid/"><b>inner</b></iframe>text`,
+			want:        `text`,
+		},
+		{
+			name:        "event handler",
+			description: `<p onmouseover="alert(1)">hover</p><img src=x onerror=alert(1)>`,
+			want:        `<p>hover</p>`,
+		},
+		{
+			name:        "event handler on link",
+			description: `<a href="https://tracker.invalid/" onclick="alert(1)">link</a>`,
+			want:        `<a href="https://tracker.invalid/" rel="nofollow noopener noreferrer">link</a>`,
+		},
+		{
+			name:        "javascript url",
+			description: `<a href="javascript:alert(1)">link</a>`,
+			want:        `<a>link</a>`,
+		},
+		{
+			name:        "obfuscated javascript url",
+			description: `<a href="  JaVaScRiPt:alert(1)">link</a><a href="java&#x09;script:alert(1)">link</a>`,
+			want:        `<a>link</a><a>link</a>`,
+		},
+		{
+			name:        "javascript url in bbcode",
+			description: `[url=javascript:alert(1)]link[/url]`,
+			want:        `<a>link</a>`,
+		},
+		{
+			name:        "data url",
+			description: `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">link</a>`,
+			want:        `<a>link</a>`,
+		},
+		{
+			name:        "magnet",
+			description: `<a href="magnet:?xt=urn:btih:abc">magnet</a>`,
+			want:        `<a href="magnet:?xt=urn:btih:abc" rel="nofollow noopener noreferrer">magnet</a>`,
+		},
+		{
+			name:        "unmatched end tags",
+			description: `</div></b>text</p>`,
+			want:        `text`,
+		},
+		{
+			name:        "unclosed tags",
+			description: `<div><b>bold`,
+			want:        `<div><b>bold</b></div>`,
+		},
+		{
+			name:        "misnested tags",
+			description: `<b><i>text</b> after</i>`,
+			want:        `<b><i>text</i></b> after`,
+		},
+		{
+			name:        "closing a dropped container",
+			description: `<div>text</div></div></td>`,
+			want:        `<div>text</div>`,
+		},
+		{
+			name:        "unclosed script",
+			description: `<b>safe</b><script>alert(1)`,
+			want:        `<b>safe</b>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := jackett.FeedItem{Description: tt.description}
+			assert.Equal(t, tt.want, item.DescriptionHTML())
+		})
+	}
+}
+
+func TestDescriptionText(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name:        "html",
+			description: `<p>Line one</p><p>Line  two &amp; more</p>`,
+			want:        "Line one\n\nLine two & more",
+		},
+		{
+			name:        "bbcode",
+			description: `[b]Bold[/b] [color=red]red[/color] [img]x.jpg[/img]`,
+			want:        "Bold red",
+		},
+		{
+			name:        "script",
+			description: `text<script>alert(1)</script>`,
+			want:        "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := jackett.FeedItem{Description: tt.description}
+			assert.Equal(t, tt.want, item.DescriptionText())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package jackett
+
+// ItemChange is an item found by both searches whose swarm changed.
+type ItemChange struct {
+	Old FeedItem
+	New FeedItem
+
+	// new minus old
+	SeedersDelta int
+	PeersDelta   int
+}
+
+// ItemsDiff is the difference between the results of two searches.
+type ItemsDiff struct {
+	// in the order of the new results
+	Added   []FeedItem
+	Changed []ItemChange
+
+	// in the order of the old results
+	Removed []FeedItem
+}
+
+// DiffItems compares the results of two searches, e.g. the same search run
+// an hour apart, to follow the seeders of releases over time. Items are
+// matched by guid, or link when they have none.
+func DiffItems(before, after []FeedItem) ItemsDiff {
+	var diff ItemsDiff
+
+	old := make(map[string]FeedItem, len(before))
+	for _, item := range before {
+		old[itemKey(item)] = item
+	}
+
+	found := make(map[string]bool, len(after))
+	for _, item := range after {
+		key := itemKey(item)
+		found[key] = true
+
+		prev, ok := old[key]
+		if !ok {
+			diff.Added = append(diff.Added, item)
+			continue
+		}
+
+		change := ItemChange{
+			Old:          prev,
+			New:          item,
+			SeedersDelta: item.Seeders() - prev.Seeders(),
+			PeersDelta:   item.Peers() - prev.Peers(),
+		}
+
+		if change.SeedersDelta != 0 || change.PeersDelta != 0 {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	for _, item := range before {
+		if !found[itemKey(item)] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	return diff
+}
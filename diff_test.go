@@ -0,0 +1,107 @@
+package jackett_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// swarmItem returns an item identified by guid, or link without one, with
+// the swarm.
+func swarmItem(guid, link string, seeders, peers int) jackett.FeedItem {
+	return jackett.FeedItem{
+		Title: guid + link,
+		Guid:  guid,
+		Link:  link,
+		Attr: []jackett.ItemAttr{
+			{Name: string(jackett.AttrSeeders), Value: strconv.Itoa(seeders)},
+			{Name: string(jackett.AttrPeers), Value: strconv.Itoa(peers)},
+		},
+	}
+}
+
+func TestDiffItems(t *testing.T) {
+	type change struct {
+		title          string
+		seeders, peers int
+	}
+
+	tests := []struct {
+		name    string
+		before  []jackett.FeedItem
+		after   []jackett.FeedItem
+		added   []string
+		changed []change
+		removed []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "all added",
+			after: []jackett.FeedItem{swarmItem("a", "", 1, 1), swarmItem("b", "", 1, 1)},
+			added: []string{"a", "b"},
+		},
+		{
+			name:    "all removed",
+			before:  []jackett.FeedItem{swarmItem("a", "", 1, 1), swarmItem("b", "", 1, 1)},
+			removed: []string{"a", "b"},
+		},
+		{
+			name:   "unchanged",
+			before: []jackett.FeedItem{swarmItem("a", "", 5, 8)},
+			after:  []jackett.FeedItem{swarmItem("a", "", 5, 8)},
+		},
+		{
+			name:    "swarm changed",
+			before:  []jackett.FeedItem{swarmItem("a", "", 5, 8), swarmItem("b", "", 3, 3)},
+			after:   []jackett.FeedItem{swarmItem("b", "", 3, 1), swarmItem("a", "", 10, 8)},
+			changed: []change{{"b", 0, -2}, {"a", 5, 0}},
+		},
+		{
+			name:    "matched by link without guid",
+			before:  []jackett.FeedItem{swarmItem("", "http://dl/1", 1, 1), swarmItem("", "http://dl/2", 1, 1)},
+			after:   []jackett.FeedItem{swarmItem("", "http://dl/1", 2, 2), swarmItem("", "http://dl/3", 1, 1)},
+			added:   []string{"http://dl/3"},
+			changed: []change{{"http://dl/1", 1, 1}},
+			removed: []string{"http://dl/2"},
+		},
+		{
+			name:    "mixed",
+			before:  []jackett.FeedItem{swarmItem("a", "", 1, 1), swarmItem("b", "", 1, 1), swarmItem("c", "", 4, 4)},
+			after:   []jackett.FeedItem{swarmItem("d", "", 1, 1), swarmItem("c", "", 2, 4), swarmItem("b", "", 1, 1)},
+			added:   []string{"d"},
+			changed: []change{{"c", -2, 0}},
+			removed: []string{"a"},
+		},
+	}
+
+	titles := func(items []jackett.FeedItem) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Title)
+		}
+
+		return out
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := jackett.DiffItems(tt.before, tt.after)
+
+			assert.Equal(t, tt.added, titles(diff.Added))
+			assert.Equal(t, tt.removed, titles(diff.Removed))
+
+			var changed []change
+			for _, c := range diff.Changed {
+				assert.Equal(t, c.Old.Title, c.New.Title)
+				changed = append(changed, change{c.New.Title, c.SeedersDelta, c.PeersDelta})
+			}
+
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
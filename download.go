@@ -0,0 +1,76 @@
+package jackett
+
+import (
+	"sort"
+	"strings"
+)
+
+type DownloadType string
+
+const (
+	DownloadTorrent DownloadType = "torrent"
+	DownloadMagnet  DownloadType = "magnet"
+	DownloadNZB     DownloadType = "nzb"
+)
+
+// DownloadSource is one way of downloading an item. Sources with a lower
+// Priority are preferred.
+type DownloadSource struct {
+	Type     DownloadType
+	URL      string
+	Priority int
+}
+
+// DownloadOptions returns every way of downloading the item, most preferred
+// first: the enclosure, the link, the magnet attribute, and finally a magnet
+// built from the infohash.
+func (i FeedItem) DownloadOptions() []DownloadSource {
+	var sources []DownloadSource
+	seen := make(map[string]bool)
+
+	add := func(rawURL, contentType string, priority int) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" || seen[rawURL] {
+			return
+		}
+
+		seen[rawURL] = true
+		sources = append(sources, DownloadSource{
+			Type:     downloadType(rawURL, contentType),
+			URL:      rawURL,
+			Priority: priority,
+		})
+	}
+
+	add(i.Enclosure.URL, i.Enclosure.Type, 0)
+
+	// the guid or comments are usually the details page rather than a download
+	if i.Link != i.Comments {
+		add(i.Link, "", 1)
+	}
+
+	add(i.GetAttr(AttrMagnetURL), "", 2)
+
+	if strings.HasPrefix(i.Guid, "magnet:") {
+		add(i.Guid, "", 2)
+	}
+
+	add(i.MagnetURI(), "", 3)
+
+	sort.SliceStable(sources, func(a, b int) bool {
+		return sources[a].Priority < sources[b].Priority
+	})
+
+	return sources
+}
+
+func downloadType(rawURL, contentType string) DownloadType {
+	switch {
+	case strings.HasPrefix(rawURL, "magnet:"):
+		return DownloadMagnet
+	case contentType == NZBContentType, strings.HasSuffix(strings.ToLower(rawURL), ".nzb"):
+		return DownloadNZB
+	}
+
+	return DownloadTorrent
+}
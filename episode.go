@@ -0,0 +1,140 @@
+package jackett
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var resolutionRegexp = regexp.MustCompile(`(?i)\b(?:(2160|1080|720|576|480)[pi]|(4k|uhd))\b`)
+
+// Episode identifies the episode, or the season for season packs, a release
+// title is for.
+type Episode struct {
+	// normalized, e.g. "the office" for The.Office.S05E01.720p
+	Series string
+
+	Season int
+
+	// 0 for season packs
+	Episode int
+}
+
+// Key returns the episode as "series|season|episode".
+func (e Episode) Key() string {
+	return e.Series + "|" + strconv.Itoa(e.Season) + "|" + strconv.Itoa(e.Episode)
+}
+
+// ParseEpisode parses the series, season and episode from a release title,
+// false when it has no season or episode tag.
+func ParseEpisode(title string) (Episode, bool) {
+	m := episodeRegexp.FindStringSubmatchIndex(title)
+	if m == nil {
+		return Episode{}, false
+	}
+
+	series := strings.Join(relevanceWords(title[:m[0]]), " ")
+	if series == "" {
+		return Episode{}, false
+	}
+
+	ep := Episode{Series: series}
+	ep.Season, _ = strconv.Atoi(title[m[2]:m[3]])
+
+	if m[4] >= 0 {
+		ep.Episode, _ = strconv.Atoi(title[m[4]:m[5]])
+	}
+
+	return ep, true
+}
+
+// Release is what can be told about a release from its title.
+type Release struct {
+	Title string
+
+	// normalized name of the series or movie, e.g. "the matrix"
+	Name string
+
+	// 0 when not in the title
+	Year int
+
+	// both 0 for movies, Episode is 0 for season packs
+	Season  int
+	Episode int
+
+	Resolution int
+	Proper     bool
+}
+
+// IsEpisode reports whether the release is an episode or season pack.
+func (r Release) IsEpisode() bool {
+	return r.Season > 0 || r.Episode > 0
+}
+
+// ParseRelease parses a release title. The name of movies is taken from the
+// title up to the year or resolution.
+func ParseRelease(title string) Release {
+	r := Release{
+		Title:      title,
+		Resolution: Resolution(title),
+		Proper:     isProper(title),
+	}
+
+	if ep, ok := ParseEpisode(title); ok {
+		r.Name, r.Season, r.Episode = ep.Series, ep.Season, ep.Episode
+	}
+
+	name := title
+	if loc := yearRegexp.FindStringIndex(title); loc != nil {
+		r.Year, _ = strconv.Atoi(title[loc[0]:loc[1]])
+		name = title[:loc[0]]
+	} else if loc := resolutionRegexp.FindStringIndex(title); loc != nil {
+		name = title[:loc[0]]
+	}
+
+	if r.Name == "" {
+		r.Name = strings.Join(relevanceWords(name), " ")
+	}
+
+	return r
+}
+
+// Resolution returns the vertical resolution in the title, e.g. 1080 for
+// 1080p, or 0 when there is none.
+func Resolution(title string) int {
+	m := resolutionRegexp.FindStringSubmatch(title)
+	if m == nil {
+		return 0
+	}
+
+	if m[2] != "" {
+		return 2160
+	}
+
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// BetterRelease reports whether a is a better release than b: higher
+// resolution first, then proper or repack releases, then more seeders.
+func BetterRelease(a, b FeedItem) bool {
+	if ra, rb := Resolution(a.Title), Resolution(b.Title); ra != rb {
+		return ra > rb
+	}
+
+	if pa, pb := isProper(a.Title), isProper(b.Title); pa != pb {
+		return pa
+	}
+
+	return a.Seeders() > b.Seeders()
+}
+
+func isProper(title string) bool {
+	for _, word := range relevanceWords(title) {
+		if word == "proper" || word == "repack" {
+			return true
+		}
+	}
+
+	return false
+}
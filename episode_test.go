@@ -0,0 +1,106 @@
+package jackett_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+func TestParseEpisode(t *testing.T) {
+	tests := []struct {
+		title string
+		want  jackett.Episode
+		ok    bool
+	}{
+		{title: "The.Office.S05E01.720p.HDTV.x264", want: jackett.Episode{Series: "the office", Season: 5, Episode: 1}, ok: true},
+		{title: "The Office S05E01 1080p WEB-DL", want: jackett.Episode{Series: "the office", Season: 5, Episode: 1}, ok: true},
+		{title: "Show.S01.1080p.BluRay", want: jackett.Episode{Series: "show", Season: 1}, ok: true},
+		{title: "One.Piece.S01E1089.1080p", want: jackett.Episode{Series: "one piece", Season: 1, Episode: 1089}, ok: true},
+		{title: "Show S2024E05 720p", ok: false},
+		{title: "The.Matrix.1999.1080p.BluRay", ok: false},
+		{title: "S01E01.1080p", ok: false},
+		{title: "Show.S01E01E02.720p", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			ep, ok := jackett.ParseEpisode(tt.title)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, ep)
+		})
+	}
+}
+
+func TestParseRelease(t *testing.T) {
+	tests := []struct {
+		title string
+		want  jackett.Release
+	}{
+		{
+			title: "The.Matrix.1999.1080p.BluRay.x264",
+			want:  jackett.Release{Name: "the matrix", Year: 1999, Resolution: 1080},
+		},
+		{
+			title: "Some Movie 2160p UHD PROPER",
+			want:  jackett.Release{Name: "some movie", Resolution: 2160, Proper: true},
+		},
+		{
+			title: "Show.S02E03.REPACK.720p",
+			want:  jackett.Release{Name: "show", Season: 2, Episode: 3, Resolution: 720, Proper: true},
+		},
+		{
+			title: "Show.2019.S01.4K",
+			want:  jackett.Release{Name: "show", Year: 2019, Season: 1, Resolution: 2160},
+		},
+		{
+			title: "Untagged Release",
+			want:  jackett.Release{Name: "untagged release"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			tt.want.Title = tt.title
+			r := jackett.ParseRelease(tt.title)
+			assert.Equal(t, tt.want, r)
+			assert.Equal(t, tt.want.Season > 0, r.IsEpisode())
+		})
+	}
+}
+
+func TestEpisodeKey(t *testing.T) {
+	ep, ok := jackett.ParseEpisode("The.Office.S05E01.720p")
+	assert.True(t, ok)
+	assert.Equal(t, "the office|5|1", ep.Key())
+
+	// another release of the same episode
+	other, _ := jackett.ParseEpisode("The Office S05E01 1080p PROPER")
+	assert.Equal(t, ep.Key(), other.Key())
+}
+
+func TestBetterRelease(t *testing.T) {
+	item := func(title string, seeders int) jackett.FeedItem {
+		return jackettest.Item(title, 5000, seeders)
+	}
+
+	tests := []struct {
+		name string
+		a, b jackett.FeedItem
+		want bool
+	}{
+		{name: "higher resolution", a: item("Show.S01E01.1080p", 1), b: item("Show.S01E01.720p", 100), want: true},
+		{name: "lower resolution", a: item("Show.S01E01.720p", 100), b: item("Show.S01E01.2160p", 1)},
+		{name: "proper", a: item("Show.S01E01.PROPER.720p", 1), b: item("Show.S01E01.720p", 100), want: true},
+		{name: "more seeders", a: item("Show.S01E01.720p", 10), b: item("Show.S01E01.720p", 5), want: true},
+		{name: "fewer seeders", a: item("Show.S01E01.720p", 5), b: item("Show.S01E01.720p", 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, jackett.BetterRelease(tt.a, tt.b))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package jackett
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types written by EventWriter.
+const (
+	EventSearch = "search"
+	EventMatch  = "match"
+	EventGrab   = "grab"
+	EventError  = "error"
+)
+
+// Event is a single line of the NDJSON event stream.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Source  string    `json:"source,omitempty"`
+	Query   string    `json:"query,omitempty"`
+	Results *int      `json:"results,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	GUID    string    `json:"guid,omitempty"`
+	Link    string    `json:"link,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// EventWriter serializes searches, matches, grabs and errors as NDJSON lines,
+// ready to be shipped to Loki, Elastic and the like. It is safe for
+// concurrent use.
+type EventWriter struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	clock Clock
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{
+		enc:   json.NewEncoder(w),
+		clock: systemClock{},
+	}
+}
+
+// Write stamps ev with the current time, unless already set, and writes it.
+func (ew *EventWriter) Write(ev Event) error {
+	if ew == nil {
+		return nil
+	}
+
+	if ev.Time.IsZero() {
+		ev.Time = ew.clock.Now().UTC()
+	}
+
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	return ew.enc.Encode(ev)
+}
+
+func (ew *EventWriter) Search(indexer string, opts map[string]string, results int, err error) error {
+	ev := Event{
+		Type:    EventSearch,
+		Source:  indexer,
+		Query:   opts["q"],
+		Results: &results,
+	}
+
+	if err != nil {
+		ev.Error = err.Error()
+		ev.Results = nil
+	}
+
+	return ew.Write(ev)
+}
+
+func (ew *EventWriter) Match(source string, item FeedItem) error {
+	return ew.Write(Event{
+		Type:   EventMatch,
+		Source: source,
+		Title:  item.Title,
+		GUID:   item.Guid,
+		Link:   redactLink(item.Link),
+	})
+}
+
+func (ew *EventWriter) Grab(source string, item FeedItem) error {
+	return ew.Write(Event{
+		Type:   EventGrab,
+		Source: source,
+		Title:  item.Title,
+		GUID:   item.Guid,
+		Link:   redactLink(item.Enclosure.URL),
+	})
+}
+
+// secretParams are the query params of links carrying credentials, e.g. the
+// api key in the download links of Jackett.
+var secretParams = []string{"jackett_apikey", "apikey", "passkey", "torrent_pass", "authkey", "rsskey", "token"}
+
+// redactLink replaces the credentials in the query of link, so events can
+// be shipped without leaking them.
+func redactLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		// not shipping what can't be checked
+		if i := strings.IndexByte(link, '?'); i >= 0 {
+			return link[:i]
+		}
+
+		return link
+	}
+
+	q := u.Query()
+
+	redacted := false
+	for key := range q {
+		for _, secret := range secretParams {
+			if strings.EqualFold(key, secret) {
+				q.Set(key, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+
+	if !redacted {
+		return link
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (ew *EventWriter) Error(source string, err error) error {
+	return ew.Write(Event{
+		Type:   EventError,
+		Source: source,
+		Error:  err.Error(),
+	})
+}
@@ -0,0 +1,70 @@
+package jackett_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+func TestEventLinksRedacted(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "jackett download",
+			link: "http://127.0.0.1:9117/dl/tracker/?jackett_apikey=secretkey&path=abc&file=Show",
+			want: "http://127.0.0.1:9117/dl/tracker/?file=Show&jackett_apikey=REDACTED&path=abc",
+		},
+		{
+			name: "tracker passkey",
+			link: "https://tracker.invalid/download.php?id=1&PassKey=secretkey",
+			want: "https://tracker.invalid/download.php?PassKey=REDACTED&id=1",
+		},
+		{
+			name: "without credentials",
+			link: "https://tracker.invalid/download.php?id=1",
+			want: "https://tracker.invalid/download.php?id=1",
+		},
+		{
+			name: "magnet",
+			link: "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=Show",
+			want: "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=Show",
+		},
+		{
+			name: "unparsable",
+			link: "http://tracker.invalid/%zz?passkey=secretkey",
+			want: "http://tracker.invalid/%zz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ew := jackett.NewEventWriter(&buf)
+
+			item := jackett.FeedItem{Title: "Show", Link: tt.link}
+			item.Enclosure.URL = tt.link
+
+			require.NoError(t, ew.Match("feed", item))
+			require.NoError(t, ew.Grab("feed", item))
+
+			assert.NotContains(t, buf.String(), "secretkey")
+
+			dec := json.NewDecoder(&buf)
+			for _, typ := range []string{jackett.EventMatch, jackett.EventGrab} {
+				var ev jackett.Event
+				require.NoError(t, dec.Decode(&ev))
+
+				assert.Equal(t, typ, ev.Type)
+				assert.Equal(t, tt.want, ev.Link)
+			}
+		})
+	}
+}
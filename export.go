@@ -0,0 +1,165 @@
+package jackett
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+type CSVColumn string
+
+const (
+	CSVTitle    CSVColumn = "title"
+	CSVSize     CSVColumn = "size"
+	CSVSeeders  CSVColumn = "seeders"
+	CSVIndexer  CSVColumn = "indexer"
+	CSVInfoHash CSVColumn = "infohash"
+	CSVMagnet   CSVColumn = "magnet"
+)
+
+var DefaultCSVColumns = []CSVColumn{CSVTitle, CSVSize, CSVSeeders, CSVIndexer, CSVInfoHash, CSVMagnet}
+
+// WriteCSV writes items as CSV with a header row. All columns are written
+// when none are given.
+func WriteCSV(w io.Writer, items []FeedItem, columns ...CSVColumn) error {
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	header := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if _, err := csvValue(FeedItem{}, col); err != nil {
+			return err
+		}
+
+		header = append(header, string(col))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return errors.Wrap(err, "could not write csv header")
+	}
+
+	for _, item := range items {
+		record := make([]string, 0, len(columns))
+		for _, col := range columns {
+			value, _ := csvValue(item, col)
+			record = append(record, value)
+		}
+
+		if err := cw.Write(record); err != nil {
+			return errors.Wrap(err, "could not write csv record")
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(item FeedItem, col CSVColumn) (string, error) {
+	switch col {
+	case CSVTitle:
+		return item.Title, nil
+	case CSVSize:
+		return strconv.FormatInt(item.SizeBytes(), 10), nil
+	case CSVSeeders:
+		return strconv.Itoa(item.Seeders()), nil
+	case CSVIndexer:
+		return item.Indexer(), nil
+	case CSVInfoHash:
+		return item.InfoHash(), nil
+	case CSVMagnet:
+		return item.MagnetURI(), nil
+	}
+
+	return "", errors.New("unknown csv column: %v", col)
+}
+
+type rss2Feed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel rss2Channel `xml:"channel"`
+}
+
+type rss2Channel struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Items       []rss2Item `xml:"item"`
+}
+
+type rss2Item struct {
+	Title     string         `xml:"title"`
+	Link      string         `xml:"link,omitempty"`
+	Guid      string         `xml:"guid,omitempty"`
+	PubDate   string         `xml:"pubDate,omitempty"`
+	Enclosure *rss2Enclosure `xml:"enclosure,omitempty"`
+}
+
+type rss2Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// WriteRSS writes items as a plain RSS 2.0 feed without any torznab
+// extensions, for consumers that only understand RSS.
+func WriteRSS(w io.Writer, title, link string, items []FeedItem) error {
+	feed := rss2Feed{
+		Version: "2.0",
+		Channel: rss2Channel{
+			Title:       title,
+			Link:        link,
+			Description: title,
+			Items:       make([]rss2Item, 0, len(items)),
+		},
+	}
+
+	for _, item := range items {
+		out := rss2Item{
+			Title:   item.Title,
+			Link:    item.Link,
+			Guid:    item.Guid,
+			PubDate: item.PubDate,
+		}
+
+		if out.Link == "" {
+			out.Link = item.Enclosure.URL
+		}
+
+		if item.Enclosure.URL != "" {
+			enc := &rss2Enclosure{
+				URL:  item.Enclosure.URL,
+				Type: item.Enclosure.Type,
+			}
+
+			enc.Length, _ = strconv.ParseInt(item.Enclosure.Length, 10, 64)
+			if enc.Length == 0 {
+				enc.Length = item.SizeBytes()
+			}
+
+			if enc.Type == "" {
+				enc.Type = "application/x-bittorrent"
+			}
+
+			out.Enclosure = enc
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, out)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return errors.Wrap(err, "could not encode rss feed")
+	}
+
+	return enc.Flush()
+}
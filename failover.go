@@ -0,0 +1,202 @@
+package jackett
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var DefaultFallbackProbeInterval = time.Minute
+
+// hostState tracks which of the hosts of the backend requests go to. Host is
+// hosts[0], the fallbacks follow in the order they were configured.
+type hostState struct {
+	hosts []string
+
+	mu      sync.Mutex
+	active  int
+	probing bool
+	probeAt time.Time
+}
+
+func newHostState(cfg Config) *hostState {
+	hosts := []string{cfg.Host}
+	for _, host := range cfg.FallbackHosts {
+		// invalid hosts are kept as is and fail at request time, see Config.Validate
+		if h, err := NormalizeHost(host); err == nil {
+			host = h
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	return &hostState{hosts: hosts}
+}
+
+// ActiveHost returns the host requests are sent to, Host unless the client
+// failed over to one of the FallbackHosts.
+func (c *Client) ActiveHost() string {
+	c.hosts.mu.Lock()
+	defer c.hosts.mu.Unlock()
+
+	return c.hosts.hosts[c.hosts.active]
+}
+
+// host returns the host new requests are built for, probing the preferred
+// hosts in the background once the probe interval passed.
+func (c *Client) host() string {
+	h := c.hosts
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.active > 0 && !h.probing && !c.clock.Now().Before(h.probeAt) {
+		h.probing = true
+		go c.probeHosts(h.active)
+	}
+
+	return h.hosts[h.active]
+}
+
+// send makes a single attempt of req. When the active host can't be reached
+// it fails over to the next host and sends req there, once per host.
+func (c *Client) send(req *http.Request, body []byte) (*http.Response, error) {
+	for switched := 0; ; switched++ {
+		tracker := newPhaseTracker(req.URL.Scheme == "https")
+		resp, err := c.do(c.http, req.WithContext(httptrace.WithClientTrace(req.Context(), tracker.trace())))
+		if err == nil {
+			return resp, nil
+		}
+
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = c.redact(uerr.URL)
+		}
+
+		err = wrapNetworkError(req.Context(), err, tracker.get())
+
+		if switched >= len(c.hosts.hosts)-1 || !unreachable(err) || req.Context().Err() != nil || !c.failover(req, err) {
+			return nil, err
+		}
+
+		resetBody(req, body)
+	}
+}
+
+// unreachable reports whether err means the host could not be reached at
+// all, as opposed to failing once connected.
+func unreachable(err error) bool {
+	var nerr *NetworkError
+	if !errors.As(err, &nerr) {
+		return false
+	}
+
+	return nerr.Phase() == PhaseDNS || nerr.Phase() == PhaseConnect
+}
+
+// failover moves req to the next host, unless another request moved on from
+// the host it was sent to already, then req follows it. It reports false for
+// requests to other servers, e.g. trackers serving enclosures.
+func (c *Client) failover(req *http.Request, err error) bool {
+	h := c.hosts
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	from := -1
+	for i, host := range h.hosts {
+		if hasHostPrefix(req.URL.String(), host) {
+			from = i
+			break
+		}
+	}
+
+	if from < 0 {
+		return false
+	}
+
+	if from == h.active {
+		h.active = (h.active + 1) % len(h.hosts)
+		h.probeAt = c.clock.Now().Add(c.probeInterval())
+
+		c.log.Warn("host unreachable, failing over", "host", h.hosts[from], "to", h.hosts[h.active], "err", err)
+	}
+
+	u, perr := url.Parse(h.hosts[h.active] + strings.TrimPrefix(req.URL.String(), h.hosts[from]))
+	if perr != nil {
+		return false
+	}
+
+	req.URL, req.Host = u, u.Host
+	return true
+}
+
+func hasHostPrefix(s, host string) bool {
+	if !strings.HasPrefix(s, host) {
+		return false
+	}
+
+	rest := s[len(host):]
+	return rest == "" || rest[0] == '/' || rest[0] == '?'
+}
+
+func (c *Client) probeInterval() time.Duration {
+	if c.cfg.FallbackProbeInterval > 0 {
+		return time.Duration(c.cfg.FallbackProbeInterval) * time.Second
+	}
+
+	return DefaultFallbackProbeInterval
+}
+
+// probeHosts checks whether a host preferred over the active one is back,
+// e.g. the LAN address after returning home, and switches to the first that
+// answers. Any response counts, only reaching the server matters.
+func (c *Client) probeHosts(active int) {
+	h := c.hosts
+
+	to := -1
+	for i := 0; i < active; i++ {
+		if c.probeHost(h.hosts[i]) {
+			to = i
+			break
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.probing = false
+	h.probeAt = c.clock.Now().Add(c.probeInterval())
+
+	// a failover while probing moved past the probed hosts
+	if to < 0 || h.active != active {
+		return
+	}
+
+	c.log.Info("host reachable again", "host", h.hosts[to], "from", h.hosts[active])
+	h.active = to
+}
+
+func (c *Client) probeHost(host string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false
+	}
+
+	drainAndClose(resp.Body)
+	return true
+}
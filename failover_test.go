@@ -0,0 +1,117 @@
+package jackett_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// hosts in order, "up" for the server, "down" for refused ones
+		hosts []string
+
+		// index of the host active after the request, -1 when it fails
+		active int
+	}{
+		{name: "preferred up", hosts: []string{"up", "down"}, active: 0},
+		{name: "preferred down", hosts: []string{"down", "up"}, active: 1},
+		{name: "several down", hosts: []string{"down", "down", "up"}, active: 2},
+		{name: "all down", hosts: []string{"down", "down"}, active: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := jackettest.NewServer("apikey")
+			defer srv.Close()
+
+			srv.AddIndexer(jackettest.NewIndexer("tracker", "Tracker", 2000))
+
+			hosts := make([]string, len(tt.hosts))
+			for n, h := range tt.hosts {
+				hosts[n] = srv.URL
+				if h == "down" {
+					hosts[n] = "http://" + refusedAddr(t)
+				}
+			}
+
+			client := jackett.NewClient(jackett.Config{
+				Host:          hosts[0],
+				FallbackHosts: hosts[1:],
+				APIKey:        "apikey",
+			})
+
+			indexers, err := client.GetIndexersCtx(context.Background())
+			if tt.active < 0 {
+				// the retries reaching none of the hosts take the backend down
+				assert.ErrorIs(t, err, jackett.ErrBackendDown)
+				assert.Empty(t, srv.Requests())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, indexers.Indexer, 1)
+			assert.Equal(t, hosts[tt.active], client.ActiveHost())
+
+			// later requests go to the active host at once
+			_, err = client.GetIndexersCtx(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, srv.Requests(), 2)
+		})
+	}
+}
+
+// TestFailoverProbe expects the client to return to the preferred host once
+// it is reachable again.
+func TestFailoverProbe(t *testing.T) {
+	interval := jackett.DefaultFallbackProbeInterval
+	jackett.DefaultFallbackProbeInterval = 10 * time.Millisecond
+	defer func() { jackett.DefaultFallbackProbeInterval = interval }()
+
+	preferred := jackettest.NewServer("apikey")
+	defer preferred.Close()
+
+	fallback := jackettest.NewServer("apikey")
+	defer fallback.Close()
+
+	for _, srv := range []*jackettest.Server{preferred, fallback} {
+		srv.AddIndexer(jackettest.NewIndexer("tracker", "Tracker", 2000))
+	}
+
+	addr := refusedAddr(t)
+
+	client := jackett.NewClient(jackett.Config{
+		Host:          "http://" + addr,
+		FallbackHosts: []string{fallback.URL},
+		APIKey:        "apikey",
+	})
+
+	_, err := client.GetIndexersCtx(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, fallback.URL, client.ActiveHost())
+
+	// the preferred host comes back on the same address
+	l, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+
+	back := &http.Server{Handler: preferred.Config.Handler}
+	go back.Serve(l)
+	defer back.Close()
+
+	assert.Eventually(t, func() bool {
+		_, err := client.GetIndexersCtx(context.Background())
+		return err == nil && client.ActiveHost() == "http://"+addr
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.NotEmpty(t, preferred.Requests())
+}
@@ -0,0 +1,170 @@
+package jackett
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Namespaces of torznab feeds.
+const (
+	TorznabNamespace = "http://torznab.com/schemas/2015/feed"
+	AtomNamespace    = "http://www.w3.org/2005/Atom"
+)
+
+// NewFeed returns a torznab feed of the items, e.g. to re-serve results to
+// Sonarr or Radarr, see WriteTorznab.
+func NewFeed(title, link string, items []FeedItem) Rss {
+	var rss Rss
+	rss.Version = "2.0"
+	rss.Atom = AtomNamespace
+	rss.Torznab = TorznabNamespace
+	rss.Channel.Title = title
+	rss.Channel.Description = title
+	rss.Channel.Link = ChannelLink{Href: link, Rel: "self", Type: "application/rss+xml"}
+	rss.Channel.Item = items
+
+	return rss
+}
+
+// WriteTorznab writes the feed as torznab XML, as Jackett serves it.
+func WriteTorznab(w io.Writer, rss Rss) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(rss); err != nil {
+		return errors.Wrap(err, "could not encode torznab feed")
+	}
+
+	return enc.Flush()
+}
+
+// torznabFeed is the layout of a torznab feed. encoding/xml can't marshal
+// prefixed names, so they are spelled out.
+type torznabFeed struct {
+	XMLName xml.Name       `xml:"rss"`
+	Version string         `xml:"version,attr"`
+	Atom    string         `xml:"xmlns:atom,attr"`
+	Torznab string         `xml:"xmlns:torznab,attr"`
+	Channel torznabChannel `xml:"channel"`
+}
+
+type torznabChannel struct {
+	AtomLink    *torznabAtomLink `xml:"atom:link,omitempty"`
+	Title       string           `xml:"title"`
+	Description string           `xml:"description"`
+	Link        string           `xml:"link,omitempty"`
+	Language    string           `xml:"language,omitempty"`
+	Category    string           `xml:"category,omitempty"`
+	Items       []FeedItem       `xml:"item"`
+}
+
+type torznabAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type torznabItem struct {
+	Title          string            `xml:"title"`
+	Guid           string            `xml:"guid,omitempty"`
+	JackettIndexer *JackettIndexer   `xml:"jackettindexer,omitempty"`
+	Type           string            `xml:"type,omitempty"`
+	Comments       string            `xml:"comments,omitempty"`
+	PubDate        string            `xml:"pubDate,omitempty"`
+	Size           string            `xml:"size,omitempty"`
+	Files          string            `xml:"files,omitempty"`
+	Grabs          string            `xml:"grabs,omitempty"`
+	Description    string            `xml:"description,omitempty"`
+	Link           string            `xml:"link,omitempty"`
+	Category       []string          `xml:"category"`
+	Enclosure      *torznabEnclosure `xml:"enclosure,omitempty"`
+	Attr           []torznabAttr     `xml:"torznab:attr"`
+}
+
+type torznabEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+}
+
+type torznabAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// MarshalXML encodes the feed as torznab XML, declaring the namespaces of
+// the torznab attributes.
+func (r Rss) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	feed := torznabFeed{
+		Version: r.Version,
+		Atom:    r.Atom,
+		Torznab: r.Torznab,
+		Channel: torznabChannel{
+			Title:       r.Channel.Title,
+			Description: r.Channel.Description,
+			Link:        r.Channel.Link.Text,
+			Language:    r.Channel.Language,
+			Category:    r.Channel.Category,
+			Items:       r.Channel.Item,
+		},
+	}
+
+	if feed.Version == "" {
+		feed.Version = "2.0"
+	}
+
+	if feed.Atom == "" {
+		feed.Atom = AtomNamespace
+	}
+
+	if feed.Torznab == "" {
+		feed.Torznab = TorznabNamespace
+	}
+
+	if l := r.Channel.Link; l.Href != "" {
+		feed.Channel.AtomLink = &torznabAtomLink{Href: l.Href, Rel: l.Rel, Type: l.Type}
+	}
+
+	start.Name = xml.Name{Local: "rss"}
+	start.Attr = nil
+
+	return e.EncodeElement(feed, start)
+}
+
+// MarshalXML encodes the item as a torznab item, its attributes as
+// torznab:attr elements.
+func (i FeedItem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	item := torznabItem{
+		Title:       i.Title,
+		Guid:        i.Guid,
+		Type:        i.Type,
+		Comments:    i.Comments,
+		PubDate:     i.PubDate,
+		Size:        i.Size,
+		Files:       i.Files,
+		Grabs:       i.Grabs,
+		Description: i.Description,
+		Link:        i.Link,
+		Category:    i.Category,
+	}
+
+	if i.Jackettindexer != (JackettIndexer{}) {
+		indexer := i.Jackettindexer
+		item.JackettIndexer = &indexer
+	}
+
+	if i.Enclosure.URL != "" {
+		item.Enclosure = &torznabEnclosure{URL: i.Enclosure.URL, Length: i.Enclosure.Length, Type: i.Enclosure.Type}
+	}
+
+	for _, attr := range i.Attr {
+		item.Attr = append(item.Attr, torznabAttr{Name: attr.Name, Value: attr.Value})
+	}
+
+	return e.EncodeElement(item, start)
+}
@@ -3,14 +3,15 @@ package jackett
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
-	"github.com/autobrr/go-qbittorrent/errors"
-	"github.com/avast/retry-go"
+	"github.com/kylesanderson/go-jackett/internal/errors"
 )
 
 func (c *Client) getRawCtx(ctx context.Context, reqUrl string) (*http.Response, error) {
@@ -19,23 +20,115 @@ func (c *Client) getRawCtx(ctx context.Context, reqUrl string) (*http.Response,
 		return nil, errors.Wrap(err, "could not build request")
 	}
 
-	if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" {
+	// never hand the credentials or key to other hosts, e.g. trackers
+	// serving enclosures
+	if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" && c.isHost(req.URL) {
 		req.SetBasicAuth(c.cfg.BasicUser, c.cfg.BasicPass)
 	}
 
+	if c.cfg.APIKeyHeader && c.cfg.APIKey != "" && c.isHost(req.URL) {
+		req.Header.Set("X-Api-Key", c.cfg.APIKey)
+	}
+
 	// try request and if fail run 10 retries
 	resp, err := c.retryDo(ctx, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "error making get request: %v", reqUrl)
+		return nil, errors.Wrap(err, "error making get request: %v", c.redact(reqUrl))
 	}
 
 	return resp, nil
 }
 
+// isHost reports whether u points at the configured host or one of its
+// fallbacks.
+func (c *Client) isHost(u *url.URL) bool {
+	for _, h := range c.hosts.hosts {
+		if host, err := url.Parse(h); err == nil && strings.EqualFold(host.Host, u.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsHostURL reports whether rawURL is an http url of the configured host or
+// one of its fallbacks, e.g. to check links received from users before
+// requesting them with the credentials of the client.
+func (c *Client) IsHostURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	return c.isHost(u)
+}
+
+// redact masks the api key in s, e.g. a url about to be logged.
+func (c *Client) redact(s string) string {
+	if c.cfg.APIKey == "" {
+		return s
+	}
+
+	return strings.NewReplacer(
+		c.cfg.APIKey, "REDACTED",
+		url.QueryEscape(c.cfg.APIKey), "REDACTED",
+	).Replace(s)
+}
+
+// requireAPIKey fails with ErrMissingAPIKey when no api key is configured,
+// instead of sending a request the server will refuse.
+func (c *Client) requireAPIKey() error {
+	if c.cfg.APIKey == "" && !c.cfg.keyless() {
+		return ErrMissingAPIKey
+	}
+
+	return nil
+}
+
 func (c *Client) getCtx(ctx context.Context, endpoint string, opts map[string]string) (*http.Response, error) {
 	return c.getRawCtx(ctx, c.buildUrl(endpoint, opts))
 }
 
+func (c *Client) getTorznabCtx(ctx context.Context, indexer, path string, opts map[string]string) (*http.Response, error) {
+	return c.getRawCtx(ctx, c.buildTorznabUrl(indexer, path, opts))
+}
+
+// getTorznabInto requests the torznab api of an indexer and decodes the
+// response into v, releasing the connection for reuse. A path overrides the
+// PathTemplate, see buildTorznabUrl.
+func (c *Client) getTorznabInto(ctx context.Context, indexer, path string, opts map[string]string, v interface{}) (err error) {
+	t := opts["t"]
+	if t == "" {
+		t = "search"
+	}
+
+	ctx, sp := c.startSpan(ctx, torznabEndpoint(t), indexer, "jackett.search_type", t)
+	defer func() { sp.end(err) }()
+
+	if err := c.requireAPIKey(); err != nil {
+		return err
+	}
+
+	if t != "caps" && t != "indexers" {
+		if err := c.spendBudget(ctx, indexer); err != nil {
+			return err
+		}
+	}
+
+	if err := c.waitRateLimit(ctx, indexer); err != nil {
+		return err
+	}
+
+	resp, err := c.getTorznabCtx(ctx, indexer, path, opts)
+	if err != nil {
+		return errors.Wrap(err, indexer+" endpoint error")
+	}
+
+	defer drainAndClose(resp.Body)
+
+	return decodeBody(resp, v)
+}
+
 func (c *Client) postCtx(ctx context.Context, endpoint string, opts map[string]string) (*http.Response, error) {
 	// add optional parameters that the user wants
 	form := url.Values{}
@@ -60,7 +153,7 @@ func (c *Client) postCtx(ctx context.Context, endpoint string, opts map[string]s
 	// try request and if fail run 10 retries
 	resp, err := c.retryDo(ctx, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "error making post request: %v", reqUrl)
+		return nil, errors.Wrap(err, "error making post request: %v", c.redact(reqUrl))
 	}
 
 	return resp, nil
@@ -89,9 +182,9 @@ func (c *Client) postBasicCtx(ctx context.Context, endpoint string, opts map[str
 	// add the content-type so qbittorrent knows what to expect
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err = c.http.Do(req)
+	resp, err = c.do(c.http, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "error making post request: %v", reqUrl)
+		return nil, errors.Wrap(err, "error making post request: %v", c.redact(reqUrl))
 	}
 
 	return resp, nil
@@ -112,7 +205,7 @@ func (c *Client) buildUrl(endpoint string, params map[string]string) string {
 		queryParams.Add(key, value)
 	}
 
-	joinedUrl, _ := url.JoinPath(c.cfg.Host, apiBase, endpoint)
+	joinedUrl, _ := url.JoinPath(c.host(), apiBase, endpoint)
 	parsedUrl, _ := url.Parse(joinedUrl)
 	parsedUrl.RawQuery = queryParams.Encode()
 
@@ -120,6 +213,164 @@ func (c *Client) buildUrl(endpoint string, params map[string]string) string {
 	return parsedUrl.String()
 }
 
+// maxErrorBodySize bounds how much of an undecodable body is included in errors.
+const maxErrorBodySize = 512
+
+// decodeBody sniffs the payload and decodes it as XML or JSON, or returns a
+// diagnostic error when the server answered with an HTML page.
+func decodeBody(resp *http.Response, v interface{}) error {
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	perr := &ParseError{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        truncateBody(bodyBytes),
+	}
+
+	var decode func([]byte, interface{}) error
+
+	switch sniffFormat(bodyBytes) {
+	case formatXML:
+		if terr := parseTorznabError(bodyBytes, resp.StatusCode); terr != nil {
+			return terr
+		}
+
+		perr.Format, decode = "xml", xml.Unmarshal
+	case formatJSON:
+		perr.Format, decode = "json", json.Unmarshal
+	case formatHTML:
+		perr.Format = "html"
+		return perr
+	default:
+		if resp.StatusCode >= 400 {
+			return &StatusError{Status: resp.StatusCode, Body: perr.Body}
+		}
+
+		return perr
+	}
+
+	if err := decode(bodyBytes, v); err != nil {
+		perr.Err = err
+		return perr
+	}
+
+	return nil
+}
+
+const (
+	formatUnknown = iota
+	formatXML
+	formatJSON
+	formatHTML
+)
+
+func sniffFormat(b []byte) int {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return formatUnknown
+	}
+
+	switch b[0] {
+	case '{', '[':
+		return formatJSON
+	case '<':
+		head := b
+		if len(head) > 256 {
+			head = head[:256]
+		}
+
+		head = bytes.ToLower(head)
+		if bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.Contains(head, []byte("<html")) {
+			return formatHTML
+		}
+
+		return formatXML
+	}
+
+	return formatUnknown
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > maxErrorBodySize {
+		return string(b[:maxErrorBodySize]) + "..."
+	}
+
+	return string(b)
+}
+
+// buildTorznabUrl returns the torznab api url of an indexer. In DirectMode the
+// Host is a standalone torznab server and PathTemplate, which may contain
+// {indexer} and {apikey}, is appended to it instead of the Jackett path, as
+// is ProwlarrPathTemplate in ModeProwlarr. A path overrides PathTemplate in
+// DirectMode when set.
+func (c *Client) buildTorznabUrl(indexer, path string, params map[string]string) string {
+	if c.cfg.Mode == ModeNZBHydra2 && !c.cfg.DirectMode {
+		return c.buildHydraUrl(indexer, params)
+	}
+
+	if !c.cfg.DirectMode && c.cfg.Mode != ModeProwlarr {
+		if _, ok := params["apikey"]; ok && c.cfg.APIKeyHeader {
+			query := make(map[string]string, len(params))
+			for k, v := range params {
+				if k != "apikey" {
+					query[k] = v
+				}
+			}
+
+			params = query
+		}
+
+		return c.buildUrl(indexer+"/results/torznab/api", params)
+	}
+
+	tmpl := c.cfg.PathTemplate
+	if path != "" && c.cfg.DirectMode {
+		tmpl = path
+	}
+
+	if tmpl == "" && c.cfg.Mode == ModeProwlarr && !c.cfg.DirectMode {
+		tmpl = ProwlarrPathTemplate
+	} else if tmpl == "" {
+		tmpl = DefaultDirectPathTemplate
+	}
+
+	// add query params, leaving out the apikey when it is part of the path
+	queryParams := url.Values{}
+	for key, value := range params {
+		if key == "apikey" && (c.cfg.APIKeyHeader || strings.Contains(tmpl, "{apikey}")) {
+			continue
+		}
+
+		queryParams.Add(key, value)
+	}
+
+	endpoint := strings.NewReplacer(
+		"{indexer}", url.PathEscape(indexer),
+		"{apikey}", url.PathEscape(c.cfg.APIKey),
+	).Replace(tmpl)
+
+	joinedUrl, _ := url.JoinPath(c.host(), endpoint)
+	parsedUrl, _ := url.Parse(joinedUrl)
+	parsedUrl.RawQuery = queryParams.Encode()
+
+	return parsedUrl.String()
+}
+
+// maxDrainSize bounds how much of an unread body is discarded so the
+// connection can be reused, larger bodies are cheaper to reconnect than read.
+const maxDrainSize = 64 << 10
+
+// drainAndClose discards what is left of body before closing it, without
+// which the transport can't reuse the keep-alive connection.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	body.Close()
+}
+
 func copyBody(src io.ReadCloser) ([]byte, error) {
 	b, err := io.ReadAll(src)
 	if err != nil {
@@ -152,33 +403,78 @@ func (c *Client) retryDo(ctx context.Context, req *http.Request) (*http.Response
 		return nil, err
 	}
 
+	// only requests to Jackett tell whether it is down, not the ones to
+	// trackers, e.g. downloading enclosures
+	backend := c.isHost(req.URL)
+	if backend {
+		if err := c.backendAllow(); err != nil {
+			return nil, err
+		}
+	}
+
 	var resp *http.Response
 
-	// try request and if fail run 10 retries
-	err = retry.Do(func() error {
-		resp, err = c.http.Do(req)
+	// try request and if fail retry up to the configured attempts
+	for n := 0; n < c.retry.Attempts; n++ {
+		if n > 0 {
+			resetBody(req, originalBody)
+		}
+
+		var retryAt time.Time
+
+		resp, err = c.send(req, originalBody)
+
+		if backend && c.backendResult(err) {
+			return nil, errors.Wrap(ErrBackendDown, "%v", err)
+		}
 
 		if err == nil {
-			if resp.StatusCode < 500 {
-				return err
-			} else if resp.StatusCode >= 500 {
-				return retry.Unrecoverable(errors.New("unrecoverable status: %v", resp.StatusCode))
+			c.log.Debug("request", "method", req.Method, "url", c.redact(req.URL.String()), "status", resp.StatusCode)
+			spanFromContext(ctx).setStatus(resp.StatusCode)
+			c.recordRateLimit(resp)
+
+			limited := resp.StatusCode == http.StatusTooManyRequests
+			retryable := limited || c.retry.retryable(resp.StatusCode)
+			if !retryable && resp.StatusCode < 500 {
+				return resp, nil
+			}
+
+			drainAndClose(resp.Body)
+
+			if !retryable {
+				return nil, errors.Wrap(&StatusError{Status: resp.StatusCode}, "error making request")
+			}
+
+			retryAt = parseRetryAfter(resp.Header, c.clock.Now())
+
+			if limited {
+				err = &RateLimitError{Status: resp.StatusCode, RetryAt: retryAt}
+			} else {
+				err = &StatusError{Status: resp.StatusCode}
 			}
 		}
 
-		retry.Delay(time.Second * 3)
+		c.log.Warn("request failed", "attempt", n, "url", c.redact(req.URL.String()), "err", err)
 
-		return err
-	},
-		retry.OnRetry(func(n uint, err error) { c.log.Printf("%q: attempt %d - %v\n", err, n, req.URL.String()) }),
-		//retry.Delay(time.Second*3),
-		retry.Attempts(5),
-		retry.MaxJitter(time.Second*1),
-	)
+		// if this is last attempt - don't wait
+		if n == c.retry.Attempts-1 {
+			break
+		}
 
-	if err != nil {
-		return nil, errors.Wrap(err, "error making request")
+		delay := c.retry.delay(n)
+		if !retryAt.IsZero() {
+			// waits longer than any backoff are left to the caller
+			if delay = retryAt.Sub(c.clock.Now()); delay > c.retry.MaxDelay {
+				break
+			}
+		}
+
+		select {
+		case <-c.clock.After(delay):
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "error making request")
+		}
 	}
 
-	return resp, nil
+	return nil, errors.Wrap(err, "error making request")
 }
@@ -0,0 +1,401 @@
+// Package httpapi exposes a jackett client over a small REST/JSON api, so
+// services written in other languages can reuse its normalization and
+// aggregation.
+//
+//	GET  /search?indexer=&t=&q=&cat=...   search one indexer, or all of them
+//	GET  /caps?indexer=                   caps of an indexer, or of all
+//	GET  /indexers                        configured indexers
+//	POST /grab                            report a grab of a found item
+//	GET  /events                          server-sent events of new releases
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+type Config struct {
+	Client *jackett.Client
+
+	// searches without an indexer, or for "all", are aggregated by Multi
+	// when set, routing by caps, instead of Jackett's own aggregate indexer
+	Multi *jackett.MultiClient
+
+	// api keys accepted in the X-Api-Key header or apikey query param,
+	// empty disables authentication
+	APIKeys []string
+
+	// streams the events published to it on /events when set
+	Broker *Broker
+}
+
+// maxGrabLinks bounds the download links remembered for grabs.
+const maxGrabLinks = 10000
+
+type Handler struct {
+	cfg Config
+	mux *http.ServeMux
+
+	// download links of the items found by searches by guid, oldest first,
+	// so grabs of them don't depend on the link in the request
+	mu        sync.Mutex
+	links     map[string]string
+	linkOrder []string
+}
+
+func New(cfg Config) *Handler {
+	h := &Handler{
+		cfg:   cfg,
+		mux:   http.NewServeMux(),
+		links: make(map[string]string),
+	}
+
+	h.mux.HandleFunc("/search", h.search)
+	h.mux.HandleFunc("/caps", h.caps)
+	h.mux.HandleFunc("/indexers", h.indexers)
+	h.mux.HandleFunc("/grab", h.grab)
+	h.mux.HandleFunc("/events", h.events)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if len(h.cfg.APIKeys) == 0 {
+		return true
+	}
+
+	key := r.Header.Get("X-Api-Key")
+	if key == "" {
+		key = r.URL.Query().Get("apikey")
+	}
+
+	for _, k := range h.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Item is a search result with the torznab attributes resolved.
+type Item struct {
+	Title      string   `json:"title"`
+	GUID       string   `json:"guid"`
+	Indexer    string   `json:"indexer"`
+	Link       string   `json:"link,omitempty"`
+	Details    string   `json:"details,omitempty"`
+	Magnet     string   `json:"magnet,omitempty"`
+	InfoHash   string   `json:"infohash,omitempty"`
+	PubDate    string   `json:"pubDate,omitempty"`
+	Size       int64    `json:"size"`
+	Seeders    int      `json:"seeders"`
+	Peers      int      `json:"peers"`
+	Categories []int    `json:"categories,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	IMDB       string   `json:"imdb,omitempty"`
+	TVDB       int      `json:"tvdb,omitempty"`
+	TMDB       int      `json:"tmdb,omitempty"`
+	Downloads  []string `json:"downloads,omitempty"`
+}
+
+// newItem converts i, passing its download urls through link when not nil.
+func newItem(i jackett.FeedItem, link func(string) string) Item {
+	item := Item{
+		Title:      i.Title,
+		GUID:       i.Guid,
+		Indexer:    i.Indexer(),
+		Link:       i.Enclosure.URL,
+		Details:    i.DetailsURL(),
+		Magnet:     i.MagnetURI(),
+		InfoHash:   i.InfoHash(),
+		PubDate:    i.PubDate,
+		Size:       i.SizeBytes(),
+		Seeders:    i.Seeders(),
+		Peers:      i.Peers(),
+		Categories: i.Categories(),
+		Language:   i.Language(),
+		IMDB:       i.IMDBID(),
+		TVDB:       i.TVDBID(),
+		TMDB:       i.TMDBID(),
+	}
+
+	if item.Link == "" {
+		item.Link = i.Link
+	}
+
+	if link == nil {
+		link = func(s string) string { return s }
+	}
+
+	item.Link = link(item.Link)
+
+	for _, src := range i.DownloadOptions() {
+		if u := link(src.URL); u != "" {
+			item.Downloads = append(item.Downloads, u)
+		}
+	}
+
+	return item
+}
+
+// remember records the download link of the found items for grabs.
+func (h *Handler) remember(items []jackett.FeedItem) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, i := range items {
+		link := i.Enclosure.URL
+		if link == "" {
+			link = i.Link
+		}
+
+		if i.Guid == "" || link == "" {
+			continue
+		}
+
+		if _, ok := h.links[i.Guid]; !ok {
+			h.linkOrder = append(h.linkOrder, i.Guid)
+		}
+
+		h.links[i.Guid] = link
+	}
+
+	for len(h.linkOrder) > maxGrabLinks {
+		delete(h.links, h.linkOrder[0])
+		h.linkOrder = h.linkOrder[1:]
+	}
+}
+
+// grabLink returns the link a grab is reported with: the link of the
+// request when it points at Jackett, else the link of the found item with the
+// guid. Any other link is refused, the client would request it with its
+// credentials.
+func (h *Handler) grabLink(req grabRequest) (string, bool) {
+	if req.Link != "" && h.cfg.Client.IsHostURL(req.Link) {
+		return req.Link, true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	link, ok := h.links[req.GUID]
+	return link, ok
+}
+
+// link returns the download url as rewritten by the client, empty when it
+// can't be rewritten rather than handing out the original.
+func (h *Handler) link(rawURL string) string {
+	link, err := h.cfg.Client.EnclosureURL(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return link
+}
+
+type searchResponse struct {
+	Items []Item `json:"items"`
+
+	// errors of the indexers searched across clients, keyed by host and
+	// indexer id
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+func (h *Handler) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	indexer := query.Get("indexer")
+
+	opts := make(map[string]string)
+	for k, v := range query {
+		if k == "indexer" || k == "apikey" || len(v) == 0 {
+			continue
+		}
+
+		opts[k] = strings.Join(v, ",")
+	}
+
+	if opts["t"] == "" {
+		opts["t"] = "search"
+	}
+
+	resp := searchResponse{Items: []Item{}}
+
+	if (indexer == "" || indexer == "all") && h.cfg.Multi != nil {
+		result, err := h.cfg.Multi.SearchCtx(r.Context(), opts)
+		if err != nil {
+			writeError(w, upstreamStatus(err), err.Error())
+			return
+		}
+
+		h.remember(result.Items)
+		for _, i := range result.Items {
+			resp.Items = append(resp.Items, newItem(i, h.link))
+		}
+
+		if len(result.Errors) > 0 {
+			resp.Errors = make(map[string]string, len(result.Errors))
+			for key, err := range result.Errors {
+				resp.Errors[key] = err.Error()
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if indexer == "" {
+		indexer = "all"
+	}
+
+	rss, err := h.cfg.Client.GetTorrentsCtx(r.Context(), indexer, opts)
+	if err != nil {
+		writeError(w, upstreamStatus(err), err.Error())
+		return
+	}
+
+	h.remember(rss.Channel.Item)
+	for _, i := range rss.Channel.Item {
+		resp.Items = append(resp.Items, newItem(i, h.link))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) caps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	indexer := r.URL.Query().Get("indexer")
+	if indexer == "" {
+		indexer = "all"
+	}
+
+	caps, err := h.cfg.Client.GetCapsForIndexerCtx(r.Context(), indexer)
+	if err != nil {
+		writeError(w, upstreamStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, caps)
+}
+
+type indexer struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+	Language   string `json:"language,omitempty"`
+	Categories []int  `json:"categories,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (h *Handler) indexers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ind, err := h.cfg.Client.GetIndexersCtx(r.Context())
+	if err != nil {
+		writeError(w, upstreamStatus(err), err.Error())
+		return
+	}
+
+	out := make([]indexer, 0, len(ind.Indexer))
+	for _, i := range ind.Indexer {
+		errText := i.Error
+		if errText == "" {
+			errText = i.LastError
+		}
+
+		out = append(out, indexer{
+			ID:         i.ID,
+			Title:      i.Title,
+			Type:       i.Type,
+			Language:   jackett.NormalizeLanguage(i.Language),
+			Categories: i.Capabilities().Categories,
+			Error:      errText,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+type grabRequest struct {
+	Title string `json:"title"`
+	GUID  string `json:"guid"`
+	Link  string `json:"link"`
+}
+
+func (h *Handler) grab(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req grabRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	link, ok := h.grabLink(req)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "link is neither a jackett link nor of a found item")
+		return
+	}
+
+	item := jackett.FeedItem{Title: req.Title, Guid: req.GUID, Link: link}
+	item.Enclosure.URL = link
+
+	if err := h.cfg.Client.ReportGrabCtx(r.Context(), item); err != nil {
+		writeError(w, upstreamStatus(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// upstreamStatus returns the status an error of the client is reported with.
+func upstreamStatus(err error) int {
+	switch {
+	case errors.Is(err, jackett.ErrIndexerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, jackett.ErrRateLimited), errors.Is(err, jackett.ErrBudgetExhausted):
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,341 @@
+package httpapi_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/httpapi"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+type searchResponse struct {
+	Items  []httpapi.Item    `json:"items"`
+	Errors map[string]string `json:"errors"`
+}
+
+// newJackett starts a fake Jackett with a movie and a tv indexer.
+func newJackett(t *testing.T) *jackettest.Server {
+	srv := jackettest.NewServer("apikey")
+	t.Cleanup(srv.Close)
+
+	srv.AddIndexer(jackettest.NewIndexer("movies", "Movies", 2000),
+		jackettest.Item("Movie 2024 1080p", 2000, 5),
+		jackettest.Item("Movie 2024 720p", 2000, 3))
+	srv.AddIndexer(jackettest.NewIndexer("tv", "TV", 5000),
+		jackettest.Item("Show S01E01 1080p", 5000, 8))
+
+	return srv
+}
+
+// serve starts the api and returns its url.
+func serve(t *testing.T, cfg httpapi.Config) string {
+	srv := httptest.NewServer(httpapi.New(cfg))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+// get requests url and decodes the json response into v, returning the
+// status.
+func get(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if v != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+
+	return resp.StatusCode
+}
+
+func titles(items []httpapi.Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Title)
+	}
+
+	return out
+}
+
+func TestAuthorization(t *testing.T) {
+	url := serve(t, httpapi.Config{Client: newJackett(t).Client(), APIKeys: []string{"one", "two"}})
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		status int
+	}{
+		{name: "missing key", status: http.StatusUnauthorized},
+		{name: "wrong key", query: "?apikey=three", status: http.StatusUnauthorized},
+		{name: "key param", query: "?apikey=one", status: http.StatusOK},
+		{name: "key header", header: "two", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, url+"/indexers"+tt.query, nil)
+			require.NoError(t, err)
+
+			if tt.header != "" {
+				req.Header.Set("X-Api-Key", tt.header)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	url := serve(t, httpapi.Config{Client: newJackett(t).Client()})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   []string
+	}{
+		{
+			name:   "indexer",
+			query:  "?indexer=movies&q=1080p",
+			status: http.StatusOK,
+			want:   []string{"Movie 2024 1080p"},
+		},
+		{
+			name:   "all",
+			query:  "?q=1080p",
+			status: http.StatusOK,
+			want:   []string{"Movie 2024 1080p", "Show S01E01 1080p"},
+		},
+		{
+			name:   "categories",
+			query:  "?cat=5000",
+			status: http.StatusOK,
+			want:   []string{"Show S01E01 1080p"},
+		},
+		{
+			name:   "no results",
+			query:  "?q=nothing",
+			status: http.StatusOK,
+		},
+		{
+			name:   "unknown indexer",
+			query:  "?indexer=missing",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp searchResponse
+			require.Equal(t, tt.status, get(t, url+"/search"+tt.query, &resp))
+
+			if tt.status == http.StatusOK {
+				assert.NotNil(t, resp.Items)
+				assert.ElementsMatch(t, tt.want, titles(resp.Items))
+			}
+		})
+	}
+}
+
+func TestSearchItem(t *testing.T) {
+	srv := newJackett(t)
+
+	item := jackettest.Item("Movie 2024 1080p", 2000, 5)
+	item.Attr = append(item.Attr,
+		jackett.ItemAttr{Name: string(jackett.AttrInfoHash), Value: "0123456789ABCDEF0123456789ABCDEF01234567"},
+		jackett.ItemAttr{Name: string(jackett.AttrIMDB), Value: "0133093"})
+	srv.SetResults("movies", item)
+
+	url := serve(t, httpapi.Config{Client: srv.Client()})
+
+	var resp searchResponse
+	require.Equal(t, http.StatusOK, get(t, url+"/search?indexer=movies", &resp))
+	require.Len(t, resp.Items, 1)
+
+	got := resp.Items[0]
+	assert.Equal(t, "movies", got.Indexer)
+	assert.Equal(t, item.Guid, got.GUID)
+	assert.Equal(t, item.Enclosure.URL, got.Link)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", got.InfoHash)
+	assert.True(t, strings.HasPrefix(got.Magnet, "magnet:?xt=urn:btih:0123456789abcdef"))
+	assert.Equal(t, "tt0133093", got.IMDB)
+	assert.Equal(t, int64(1<<30), got.Size)
+	assert.Equal(t, 5, got.Seeders)
+	assert.Equal(t, []int{2000}, got.Categories)
+}
+
+func TestSearchMulti(t *testing.T) {
+	first := newJackett(t)
+	second := newJackett(t)
+	second.Fail("tv", jackettest.Failure{Code: jackett.ErrCodeIncorrectCredentials, Description: "Login failed"})
+
+	multi := jackett.NewMultiClient(first.Client(), second.Client())
+	url := serve(t, httpapi.Config{Client: first.Client(), Multi: multi})
+
+	var resp searchResponse
+	require.Equal(t, http.StatusOK, get(t, url+"/search?q=1080p", &resp))
+
+	// the same torrents of both are merged
+	assert.ElementsMatch(t, []string{"Movie 2024 1080p", "Show S01E01 1080p"}, titles(resp.Items))
+	assert.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors, second.URL+"/tv")
+
+	// searches of an indexer go to the client alone
+	require.Equal(t, http.StatusOK, get(t, url+"/search?indexer=tv", &resp))
+	assert.Equal(t, []string{"Show S01E01 1080p"}, titles(resp.Items))
+}
+
+func TestIndexers(t *testing.T) {
+	url := serve(t, httpapi.Config{Client: newJackett(t).Client()})
+
+	var indexers []struct {
+		ID         string `json:"id"`
+		Categories []int  `json:"categories"`
+	}
+
+	require.Equal(t, http.StatusOK, get(t, url+"/indexers", &indexers))
+	require.Len(t, indexers, 2)
+	assert.Equal(t, "movies", indexers[0].ID)
+	assert.Equal(t, []int{2000}, indexers[0].Categories)
+
+	var caps jackett.Caps
+	require.Equal(t, http.StatusOK, get(t, url+"/caps?indexer=tv", &caps))
+	assert.Equal(t, []int{5000}, caps.CategoryIDs())
+}
+
+func TestGrab(t *testing.T) {
+	var grabs int32
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&grabs, 1)
+	}))
+	defer tracker.Close()
+
+	srv := newJackett(t)
+
+	item := jackettest.Item("Movie 2024 1080p", 2000, 5)
+	item.Link = tracker.URL + "/download/1"
+	item.Enclosure.URL = item.Link
+	srv.SetResults("movies", item)
+
+	url := serve(t, httpapi.Config{Client: srv.Client()})
+
+	var found searchResponse
+	require.Equal(t, http.StatusOK, get(t, url+"/search?indexer=movies", &found))
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		grabs  int32
+	}{
+		{
+			name:   "found item",
+			body:   `{"title":"Movie 2024 1080p","guid":"` + item.Guid + `"}`,
+			status: http.StatusNoContent,
+			grabs:  1,
+		},
+		{
+			name:   "found item with another link",
+			body:   `{"guid":"` + item.Guid + `","link":"https://evil.invalid/steal"}`,
+			status: http.StatusNoContent,
+			grabs:  1,
+		},
+		{
+			name:   "unknown link",
+			body:   `{"guid":"unknown","link":"` + tracker.URL + `/download/2"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			// reported to the fake Jackett, which serves no downloads
+			name:   "jackett link",
+			body:   `{"guid":"unknown","link":"` + srv.URL + `/dl/movies/?path=1"}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "invalid request",
+			body:   `{"guid":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "method",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&grabs, 0)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			req, err := http.NewRequest(method, url+"/grab", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.grabs, atomic.LoadInt32(&grabs))
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	broker := httpapi.NewBroker()
+	url := serve(t, httpapi.Config{Client: newJackett(t).Client(), Broker: broker})
+
+	resp, err := http.Get(url + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// subscribed once the headers are sent
+	broker.Publish(jackett.WatchEvent{Feed: "movies", Item: jackettest.Item("Movie 2024 1080p", 2000, 5)})
+
+	r := bufio.NewReader(resp.Body)
+
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: release\n", line)
+
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+
+	var ev struct {
+		Feed string       `json:"feed"`
+		Item httpapi.Item `json:"item"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev))
+	assert.Equal(t, "movies", ev.Feed)
+	assert.Equal(t, "Movie 2024 1080p", ev.Item.Title)
+}
+
+func TestEventsWithoutBroker(t *testing.T) {
+	url := serve(t, httpapi.Config{Client: newJackett(t).Client()})
+	assert.Equal(t, http.StatusNotFound, get(t, url+"/events", nil))
+}
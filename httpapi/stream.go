@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// KeepAliveInterval is how often idle event streams are sent a comment, so
+// proxies don't close them.
+var KeepAliveInterval = 30 * time.Second
+
+// streamBuffer is the number of events buffered per subscriber, slower
+// subscribers miss events rather than holding up the others.
+const streamBuffer = 64
+
+// Broker fans watcher events out to the subscribers of the /events
+// server-sent events stream.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[chan streamEvent]struct{}
+}
+
+type streamEvent struct {
+	name string
+	data []byte
+}
+
+type eventPayload struct {
+	Feed  string `json:"feed"`
+	Item  *Item  `json:"item,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[chan streamEvent]struct{})}
+}
+
+// Publish sends ev to every subscriber as a release or error event.
+func (b *Broker) Publish(ev jackett.WatchEvent) {
+	payload := eventPayload{Feed: ev.Feed}
+	name := "release"
+
+	if ev.Err != nil {
+		payload.Error = ev.Err.Error()
+		name = "error"
+	} else {
+		item := newItem(ev.Item, nil)
+		payload.Item = &item
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub <- streamEvent{name: name, data: data}:
+		default:
+		}
+	}
+}
+
+func (b *Broker) subscribe() chan streamEvent {
+	sub := make(chan streamEvent, streamBuffer)
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+func (b *Broker) unsubscribe(sub chan streamEvent) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.Broker == nil {
+		writeError(w, http.StatusNotFound, "no event stream configured")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	sub := h.cfg.Broker.subscribe()
+	defer h.cfg.Broker.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(KeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case ev := <-sub:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,112 @@
+package jackett
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// ModeNZBHydra2 selects an NZBHydra2 server, which aggregates its indexers
+// behind a single torznab endpoint. Searches of an indexer are limited to it
+// with the indexers param, "all" searches every indexer.
+const ModeNZBHydra2 = "nzbhydra2"
+
+// NZBHydraPath is the path of the torznab api of NZBHydra2.
+var NZBHydraPath = "/torznab/api"
+
+// HydraStats is the part of the NZBHydra2 stats that covers its indexers.
+type HydraStats struct {
+	NumberOfConfiguredIndexers int `json:"numberOfConfiguredIndexers"`
+	NumberOfEnabledIndexers    int `json:"numberOfEnabledIndexers"`
+
+	AvgResponseTimes      []HydraResponseTime  `json:"avgResponseTimes"`
+	IndexerAPIAccessStats []HydraIndexerAccess `json:"indexerApiAccessStats"`
+}
+
+type HydraResponseTime struct {
+	Indexer string `json:"indexer"`
+
+	// milliseconds
+	AvgResponseTime int64 `json:"avgResponseTime"`
+}
+
+type HydraIndexerAccess struct {
+	IndexerName            string  `json:"indexerName"`
+	PercentSuccessful      float64 `json:"percentSuccessful"`
+	PercentConnectionError float64 `json:"percentConnectionError"`
+	AverageAccessesPerDay  float64 `json:"averageAccessesPerDay"`
+}
+
+// SearchHydraCtx searches the indexers of an NZBHydra2 server through its
+// aggregated endpoint, all of them when indexers is empty. See GetTorrentsCtx.
+func (c *Client) SearchHydraCtx(ctx context.Context, indexers []string, opts map[string]string) (Rss, error) {
+	if len(indexers) == 0 {
+		return c.GetTorrentsCtx(ctx, "all", opts)
+	}
+
+	params := make(map[string]string, len(opts)+1)
+	for k, v := range opts {
+		params[k] = v
+	}
+
+	params["indexers"] = strings.Join(indexers, ",")
+
+	return c.GetTorrentsCtx(ctx, "all", params)
+}
+
+// GetHydraStatsCtx returns the indexer statistics of an NZBHydra2 server.
+func (c *Client) GetHydraStatsCtx(ctx context.Context) (HydraStats, error) {
+	var stats HydraStats
+	if err := c.requireAPIKey(); err != nil {
+		return stats, err
+	}
+
+	params := url.Values{}
+	if c.cfg.APIKey != "" {
+		params.Set("apikey", c.cfg.APIKey)
+	}
+
+	reqUrl, _ := url.JoinPath(c.host(), "/api/stats")
+	reqUrl += "?" + params.Encode()
+
+	resp, err := c.getRawCtx(ctx, reqUrl)
+	if err != nil {
+		return stats, errors.Wrap(err, "could not get hydra stats")
+	}
+
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return stats, &StatusError{Status: resp.StatusCode, Body: truncateBody(b)}
+	}
+
+	err = decodeBody(resp, &stats)
+	return stats, err
+}
+
+// buildHydraUrl returns the torznab api url of NZBHydra2 for a search of the
+// indexer.
+func (c *Client) buildHydraUrl(indexer string, params map[string]string) string {
+	queryParams := url.Values{}
+	for key, value := range params {
+		if key == "apikey" && c.cfg.APIKeyHeader {
+			continue
+		}
+
+		queryParams.Add(key, value)
+	}
+
+	if indexer != "" && indexer != "all" {
+		queryParams.Set("indexers", indexer)
+	}
+
+	joinedUrl, _ := url.JoinPath(c.host(), NZBHydraPath)
+	parsedUrl, _ := url.Parse(joinedUrl)
+	parsedUrl.RawQuery = queryParams.Encode()
+
+	return parsedUrl.String()
+}
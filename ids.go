@@ -0,0 +1,118 @@
+package jackett
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExternalIDs are the identifiers of the content of an item in external
+// databases. Unknown identifiers are left empty.
+type ExternalIDs struct {
+	// normalized to the tt-prefixed form, e.g. tt0133093
+	IMDB string
+
+	TMDB   int
+	TVDB   int
+	TVMaze int
+	TVRage int
+	Trakt  int
+}
+
+// IMDBID returns the tt-prefixed IMDB id of the item.
+func (i FeedItem) IMDBID() string {
+	id := i.GetAttr(AttrIMDBID)
+	if id == "" {
+		id = i.GetAttr(AttrIMDB)
+	}
+
+	return normalizeIMDBID(id)
+}
+
+func (i FeedItem) TMDBID() int {
+	return i.intAttr(AttrTMDBID)
+}
+
+func (i FeedItem) TVDBID() int {
+	return i.intAttr(AttrTVDBID)
+}
+
+func (i FeedItem) TVMazeID() int {
+	return i.intAttr(AttrTVMazeID)
+}
+
+func (i FeedItem) TVRageID() int {
+	if id := i.intAttr(AttrRageID); id != 0 {
+		return id
+	}
+
+	return i.intAttr(AttrTVRageID)
+}
+
+func (i FeedItem) TraktID() int {
+	return i.intAttr(AttrTraktID)
+}
+
+func (i FeedItem) ExternalIDs() ExternalIDs {
+	return ExternalIDs{
+		IMDB:   i.IMDBID(),
+		TMDB:   i.TMDBID(),
+		TVDB:   i.TVDBID(),
+		TVMaze: i.TVMazeID(),
+		TVRage: i.TVRageID(),
+		Trakt:  i.TraktID(),
+	}
+}
+
+func (i FeedItem) intAttr(name AttrName) int {
+	n, _ := strconv.Atoi(i.GetAttr(name))
+	return n
+}
+
+func normalizeIMDBID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	id = strings.TrimPrefix(id, "tt")
+
+	n, err := strconv.Atoi(id)
+	if err != nil || n == 0 {
+		return ""
+	}
+
+	// imdb ids have at least seven digits
+	return fmt.Sprintf("tt%07d", n)
+}
+
+// MatchesIDs reports whether item corresponds to the wanted ids: at least
+// one id set in want is reported by the item and equal, and none differ.
+// Items that report none of the wanted ids do not match, since trackers
+// ignoring id params return unrelated results.
+func MatchesIDs(item FeedItem, want ExternalIDs) bool {
+	matched, conflict := compareIDs(item.ExternalIDs(), want)
+	return matched && !conflict
+}
+
+// compareIDs reports whether any id wanted and known on both sides is
+// equal, and whether any differs.
+func compareIDs(have, want ExternalIDs) (matched, conflict bool) {
+	check := func(wanted, got, equal bool) {
+		if !wanted || !got {
+			return
+		}
+
+		if equal {
+			matched = true
+		} else {
+			conflict = true
+		}
+	}
+
+	imdb := normalizeIMDBID(want.IMDB)
+	check(imdb != "", have.IMDB != "", have.IMDB == imdb)
+	check(want.TMDB != 0, have.TMDB != 0, have.TMDB == want.TMDB)
+	check(want.TVDB != 0, have.TVDB != 0, have.TVDB == want.TVDB)
+	check(want.TVMaze != 0, have.TVMaze != 0, have.TVMaze == want.TVMaze)
+	check(want.TVRage != 0, have.TVRage != 0, have.TVRage == want.TVRage)
+	check(want.Trakt != 0, have.Trakt != 0, have.Trakt == want.Trakt)
+
+	return matched, conflict
+}
@@ -0,0 +1,125 @@
+package jackett
+
+import (
+	"context"
+	"strconv"
+)
+
+func (i Indexer) HasError() bool {
+	return i.Error != "" || i.LastError != ""
+}
+
+// Failing returns the indexers that report an error, usually because they
+// need to be re-authenticated.
+func (i Indexers) Failing() []Indexer {
+	var failing []Indexer
+	for _, ind := range i.Indexer {
+		if ind.HasError() {
+			failing = append(failing, ind)
+		}
+	}
+
+	return failing
+}
+
+// SupportsCategory reports whether the indexer caps list the category or
+// another category of the same parent group.
+func (i Indexer) SupportsCategory(cat int) bool {
+	for _, c := range i.Caps.Categories.Category {
+		if sameCategoryGroup(c.ID, cat) {
+			return true
+		}
+
+		for _, sub := range c.Subcat {
+			if sameCategoryGroup(sub.ID, cat) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SupportsAnyCategory reports whether the indexer supports one of cats, or
+// true when cats is empty.
+func (i Indexer) SupportsAnyCategory(cats []int) bool {
+	if len(cats) == 0 {
+		return true
+	}
+
+	for _, cat := range cats {
+		if i.SupportsCategory(cat) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameCategoryGroup(id string, cat int) bool {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return false
+	}
+
+	// tracker specific categories have no standard parent
+	if n >= 100000 || cat >= 100000 {
+		return n == cat
+	}
+
+	return n/1000 == cat/1000
+}
+
+// StandardCategory maps a tracker specific category onto the closest standard
+// category using the category names from the indexer caps. Standard
+// categories are returned as is, unknown ones as 0.
+func (i Indexer) StandardCategory(cat int) int {
+	if !IsCustomCategory(cat) {
+		return cat
+	}
+
+	id := strconv.Itoa(cat)
+	for _, c := range i.Caps.Categories.Category {
+		if c.ID == id {
+			return ClosestStandardCategory(c.Name)
+		}
+
+		for _, sub := range c.Subcat {
+			if sub.ID == id {
+				// subcategories of a custom category inherit its parent when nothing closer matches
+				if std := ClosestStandardCategory(sub.Name); std != 0 {
+					return std
+				}
+
+				return ClosestStandardCategory(c.Name)
+			}
+		}
+	}
+
+	return 0
+}
+
+// MissingIndexersCtx cross-checks the results of a search against the "all"
+// indexer with the configured indexers and returns those that contributed no
+// items. Jackett silently drops failing indexers from aggregate searches, so
+// these either had no matches or are broken; see Indexer.HasError.
+func (c *Client) MissingIndexersCtx(ctx context.Context, rss Rss) ([]Indexer, error) {
+	ind, err := c.GetIndexersCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contributed := make(map[string]bool)
+	for _, item := range rss.Channel.Item {
+		contributed[item.Indexer()] = true
+	}
+
+	var missing []Indexer
+	for _, i := range ind.Indexer {
+		if !contributed[i.ID] {
+			missing = append(missing, i)
+		}
+	}
+
+	return missing, nil
+}
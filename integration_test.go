@@ -0,0 +1,283 @@
+//go:build integration
+
+package jackett_test
+
+// The integration suite runs against a real Jackett, catching api drift the
+// httptest servers miss. It starts a Jackett container with docker:
+//
+//	go test -tags integration -run Integration -v .
+//
+// or uses the Jackett at JACKETT_HOST with JACKETT_API_KEY, and
+// JACKETT_ADMIN_PASSWORD when its dashboard is protected. The public indexer
+// JACKETT_INDEXER, nyaasi by default, is configured and searched for
+// JACKETT_QUERY.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+const defaultJackettImage = "lscr.io/linuxserver/jackett:latest"
+
+var integration struct {
+	client  *jackett.Client
+	indexer string
+	query   string
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	integration.indexer = envOr("JACKETT_INDEXER", "nyaasi")
+	integration.query = envOr("JACKETT_QUERY", "1080p")
+
+	host, apiKey := os.Getenv("JACKETT_HOST"), os.Getenv("JACKETT_API_KEY")
+	if host == "" {
+		container, err := startJackett(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not start jackett:", err)
+			return 1
+		}
+
+		defer exec.Command("docker", "rm", "-f", container).Run()
+
+		if host, apiKey, err = jackettAddress(ctx, container); err != nil {
+			fmt.Fprintln(os.Stderr, "could not reach jackett:", err)
+			return 1
+		}
+	}
+
+	integration.client = jackett.NewClient(jackett.Config{
+		Host:          host,
+		APIKey:        apiKey,
+		AdminPassword: os.Getenv("JACKETT_ADMIN_PASSWORD"),
+		Timeout:       60,
+	})
+
+	if err := waitReady(ctx, integration.client); err != nil {
+		fmt.Fprintln(os.Stderr, "jackett is not ready:", err)
+		return 1
+	}
+
+	if err := configureIndexer(ctx, integration.client, integration.indexer); err != nil {
+		fmt.Fprintln(os.Stderr, "could not configure indexer:", err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+// startJackett starts a Jackett container listening on a random local port
+// and returns its id.
+func startJackett(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", "run", "-d",
+		"-p", "127.0.0.1::9117",
+		"-e", "TZ=UTC",
+		envOr("JACKETT_IMAGE", defaultJackettImage),
+	).Output()
+	if err != nil {
+		return "", commandError(err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// jackettAddress returns the url of the container and its api key, read
+// from the server config once Jackett wrote it.
+func jackettAddress(ctx context.Context, container string) (string, string, error) {
+	out, err := exec.CommandContext(ctx, "docker", "port", container, "9117/tcp").Output()
+	if err != nil {
+		return "", "", commandError(err)
+	}
+
+	// one line per address family, e.g. 127.0.0.1:49153
+	addr := strings.Fields(string(out))
+	if len(addr) == 0 {
+		return "", "", fmt.Errorf("port 9117 is not published")
+	}
+
+	for {
+		out, err := exec.CommandContext(ctx, "docker", "exec", container, "cat", "/config/Jackett/ServerConfig.json").Output()
+		if err == nil {
+			var cfg struct {
+				APIKey string
+			}
+
+			if err := json.Unmarshal(out, &cfg); err == nil && cfg.APIKey != "" {
+				return "http://" + addr[0], cfg.APIKey, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func commandError(err error) error {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(exit.Stderr))
+	}
+
+	return err
+}
+
+// waitReady waits for the torznab api to answer.
+func waitReady(ctx context.Context, c *jackett.Client) error {
+	for {
+		_, err := c.GetIndexersCtx(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// configureIndexer adds the indexer with the defaults Jackett offers for
+// it, unless it is configured already.
+func configureIndexer(ctx context.Context, c *jackett.Client, id string) error {
+	configured, err := c.GetAdminIndexersCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ind := range configured {
+		if ind.ID == id {
+			return nil
+		}
+	}
+
+	return c.AddIndexerCtx(ctx, id, nil)
+}
+
+func integrationContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func TestIntegrationIndexers(t *testing.T) {
+	ctx := integrationContext(t)
+
+	indexers, err := integration.client.GetIndexersCtx(ctx)
+	require.NoError(t, err)
+
+	var found bool
+	for _, ind := range indexers.Indexer {
+		if ind.ID == integration.indexer {
+			found = true
+			assert.Equal(t, "true", ind.Configured)
+			assert.NotEmpty(t, ind.Capabilities().Categories)
+		}
+	}
+
+	assert.True(t, found, "indexer %v is not listed", integration.indexer)
+}
+
+func TestIntegrationCaps(t *testing.T) {
+	ctx := integrationContext(t)
+
+	for _, indexer := range []string{integration.indexer, "all"} {
+		caps, err := integration.client.GetCapsForIndexerCtx(ctx, indexer)
+		require.NoError(t, err, indexer)
+
+		assert.True(t, caps.SupportsMode(jackett.SearchModeSearch), indexer)
+		assert.True(t, caps.Supports(jackett.SearchModeSearch, "q"), indexer)
+		assert.NotEmpty(t, caps.Categories, indexer)
+		assert.Greater(t, caps.Limits.Max, 0, indexer)
+	}
+}
+
+func TestIntegrationSearch(t *testing.T) {
+	ctx := integrationContext(t)
+
+	rss, err := integration.client.GetTorrentsCtx(ctx, integration.indexer, map[string]string{
+		"t": jackett.SearchTypeSearch,
+		"q": integration.query,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, rss.Channel.Item, "no results for %q", integration.query)
+
+	for _, item := range rss.Channel.Item {
+		assert.NotEmpty(t, item.Title)
+		assert.NotEmpty(t, item.Guid, item.Title)
+		assert.False(t, item.PublishDate().IsZero(), item.Title)
+		assert.Greater(t, item.SizeBytes(), int64(0), item.Title)
+		assert.NotEmpty(t, item.Categories(), item.Title)
+		assert.Equal(t, integration.indexer, item.Indexer(), item.Title)
+		assert.NotEmpty(t, item.Enclosure.URL, item.Title)
+	}
+
+	// the aggregate indexer returns the same torrents
+	all, err := integration.client.GetTorrentsCtx(ctx, "all", map[string]string{
+		"t": jackett.SearchTypeSearch,
+		"q": integration.query,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, all.Channel.Item)
+}
+
+func TestIntegrationEnclosure(t *testing.T) {
+	ctx := integrationContext(t)
+
+	rss, err := integration.client.GetTorrentsCtx(ctx, integration.indexer, map[string]string{
+		"t": jackett.SearchTypeSearch,
+		"q": integration.query,
+	})
+	require.NoError(t, err)
+
+	var link string
+	for _, item := range rss.Channel.Item {
+		if u := item.Enclosure.URL; u != "" && !strings.HasPrefix(u, "magnet:") {
+			link = u
+			break
+		}
+	}
+
+	if link == "" {
+		t.Skipf("%v returned magnet links only", integration.indexer)
+	}
+
+	b, err := integration.client.GetEnclosureCtx(ctx, link)
+	require.NoError(t, err)
+
+	// a bencoded dictionary
+	require.NotEmpty(t, b)
+	assert.Equal(t, byte('d'), b[0])
+	assert.Contains(t, string(b), "4:info")
+}
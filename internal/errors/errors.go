@@ -0,0 +1,35 @@
+// Package errors provides the error helpers used throughout the package with
+// the same wrapping semantics as github.com/autobrr/go-qbittorrent/errors,
+// without pulling in that module.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	As     = errors.As
+	Is     = errors.Is
+	Unwrap = errors.Unwrap
+)
+
+// Sentinel creates an error intended to be compared against with Is.
+func Sentinel(msg string) error {
+	return errors.New(msg)
+}
+
+// New creates an error, interpolating the message parameters.
+func New(msg string, args ...interface{}) error {
+	return fmt.Errorf(msg, args...)
+}
+
+// Wrap decorates cause with a message prefix, keeping it reachable through
+// Is and As. It returns nil when cause is nil.
+func Wrap(cause error, msg string, args ...interface{}) error {
+	if cause == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", fmt.Sprintf(msg, args...), cause)
+}
@@ -0,0 +1,129 @@
+package jackett
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetAttr returns the value of the torznab attribute with the given name.
+func (i FeedItem) GetAttr(name AttrName) string {
+	for _, attr := range i.Attr {
+		if strings.EqualFold(attr.Name, string(name)) {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (i FeedItem) Indexer() string {
+	return i.Jackettindexer.ID
+}
+
+func (i FeedItem) SizeBytes() int64 {
+	size, err := strconv.ParseInt(i.Size, 10, 64)
+	if err != nil {
+		size, _ = strconv.ParseInt(i.GetAttr(AttrSize), 10, 64)
+	}
+
+	return size
+}
+
+func (i FeedItem) Seeders() int {
+	seeders, _ := strconv.Atoi(i.GetAttr(AttrSeeders))
+	return seeders
+}
+
+func (i FeedItem) Peers() int {
+	peers, _ := strconv.Atoi(i.GetAttr(AttrPeers))
+	return peers
+}
+
+// DetailsURL returns the details page of the release on the tracker: the
+// infourl attribute, the comments link without its #comments anchor, or the
+// guid when it is a link other than the download. It returns "" when none
+// of them is a web page.
+func (i FeedItem) DetailsURL() string {
+	for _, candidate := range []string{i.GetAttr(AttrInfoURL), i.Comments, i.Guid} {
+		candidate = strings.TrimSpace(candidate)
+		if !strings.HasPrefix(candidate, "http://") && !strings.HasPrefix(candidate, "https://") {
+			continue
+		}
+
+		if candidate == i.Link || candidate == i.Enclosure.URL {
+			continue
+		}
+
+		return strings.TrimSuffix(candidate, "#comments")
+	}
+
+	return ""
+}
+
+func (i FeedItem) InfoHash() string {
+	return strings.ToLower(i.GetAttr(AttrInfoHash))
+}
+
+// MagnetURI returns the magnet link of the item, building one from the
+// infohash when the indexer does not provide it.
+func (i FeedItem) MagnetURI() string {
+	if magnet := i.GetAttr(AttrMagnetURL); magnet != "" {
+		return magnet
+	}
+
+	if strings.HasPrefix(i.Link, "magnet:") {
+		return i.Link
+	}
+
+	if hash := i.InfoHash(); hash != "" {
+		return "magnet:?xt=urn:btih:" + hash + "&dn=" + url.QueryEscape(i.Title)
+	}
+
+	return ""
+}
+
+func (i FeedItem) Categories() []int {
+	var cats []int
+	for _, c := range i.Category {
+		if cat, err := strconv.Atoi(c); err == nil {
+			cats = append(cats, cat)
+		}
+	}
+
+	return cats
+}
+
+// StandardCategories returns the standard categories of the item, mapping
+// tracker specific ones through the caps of the indexer it came from.
+func (i FeedItem) StandardCategories(ind Indexer) []int {
+	var cats []int
+	seen := make(map[int]bool)
+	for _, cat := range i.Categories() {
+		std := ind.StandardCategory(cat)
+		if std == 0 || seen[std] {
+			continue
+		}
+
+		seen[std] = true
+		cats = append(cats, std)
+	}
+
+	return cats
+}
+
+var pubDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700"}
+
+// PublishDate parses the pubDate of the item, the zero time when it is
+// missing or malformed.
+func (i FeedItem) PublishDate() time.Time {
+	s := strings.TrimSpace(i.PubDate)
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
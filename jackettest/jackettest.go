@@ -0,0 +1,398 @@
+// Package jackettest provides a fake Jackett for integration tests of code
+// using the jackett package, serving the torznab api of canned indexers
+// with configurable results, errors and latency.
+//
+//	srv := jackettest.NewServer("apikey")
+//	defer srv.Close()
+//
+//	srv.AddIndexer(jackettest.NewIndexer("iptorrents", "IPTorrents", 2000, 5000),
+//		jackettest.Item("Ubuntu 24.04", 2000, 42))
+//	client := srv.Client()
+package jackettest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// Failure is the response of a failing indexer. A Code sends a torznab error
+// document with the Status, defaulting to 200, as Jackett does. Without a
+// Code the Status is sent with a plain body, e.g. 503 for an overloaded
+// server.
+type Failure struct {
+	Status      int
+	Code        int
+	Description string
+}
+
+// Request is a torznab request received by the server.
+type Request struct {
+	Indexer string
+	Params  url.Values
+}
+
+// Server is a fake Jackett serving the torznab api of its indexers over
+// httptest. Searches match items whose title contains the q param, in any
+// of the cat param categories, honoring limit and offset. The "all" indexer
+// searches every indexer. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// required in the apikey param or X-Api-Key header when set
+	APIKey string
+
+	mu       sync.Mutex
+	indexers []jackett.Indexer
+	items    map[string][]jackett.FeedItem
+	failures map[string]Failure
+	latency  map[string]time.Duration
+	requests []Request
+}
+
+// NewServer starts a server requiring the api key, none when empty. Close it
+// when done.
+func NewServer(apiKey string) *Server {
+	s := &Server{
+		APIKey:   apiKey,
+		items:    make(map[string][]jackett.FeedItem),
+		failures: make(map[string]Failure),
+		latency:  make(map[string]time.Duration),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Client returns a client of the server, making a single attempt per request.
+func (s *Server) Client() *jackett.Client {
+	key := s.APIKey
+	if key == "" {
+		// any key is accepted, the client requires one
+		key = "jackettest"
+	}
+
+	return jackett.NewClient(jackett.Config{
+		Host:         s.URL,
+		APIKey:       key,
+		DisableRetry: true,
+	})
+}
+
+// NewIndexer returns a configured public indexer offering search, tv-search
+// and movie-search in the categories.
+func NewIndexer(id, title string, categories ...int) jackett.Indexer {
+	ind := jackett.Indexer{ID: id, Configured: "true", Title: title, Language: "en-US", Type: "public"}
+
+	ind.Caps.Server.Title = title
+	ind.Caps.Limits.Default = "100"
+	ind.Caps.Limits.Max = "100"
+
+	s := &ind.Caps.Searching
+	s.Search.Available, s.Search.SupportedParams = "yes", "q"
+	s.TvSearch.Available, s.TvSearch.SupportedParams = "yes", "q,season,ep,imdbid,tvdbid"
+	s.MovieSearch.Available, s.MovieSearch.SupportedParams = "yes", "q,imdbid,tmdbid"
+	s.MusicSearch.Available = "no"
+	s.AudioSearch.Available = "no"
+	s.BookSearch.Available = "no"
+
+	for _, cat := range categories {
+		ind.Caps.Categories.Category = append(ind.Caps.Categories.Category, struct {
+			Text   string `xml:",chardata"`
+			ID     string `xml:"id,attr"`
+			Name   string `xml:"name,attr"`
+			Subcat []struct {
+				Text string `xml:",chardata"`
+				ID   string `xml:"id,attr"`
+				Name string `xml:"name,attr"`
+			} `xml:"subcat"`
+		}{ID: strconv.Itoa(cat), Name: jackett.CategoryName(cat)})
+	}
+
+	return ind
+}
+
+// Item returns a torrent result in the category with the seeders, its guid
+// derived from the title.
+func Item(title string, category, seeders int) jackett.FeedItem {
+	guid := "https://tracker.invalid/details/" + url.PathEscape(title)
+
+	return jackett.FeedItem{
+		Title:    title,
+		Guid:     guid,
+		Comments: guid,
+		PubDate:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC1123Z),
+		Size:     "1073741824",
+		Link:     guid + ".torrent",
+		Category: []string{strconv.Itoa(category)},
+		Enclosure: jackett.Enclosure{
+			URL:    guid + ".torrent",
+			Length: "1073741824",
+			Type:   "application/x-bittorrent",
+		},
+		Attr: []jackett.ItemAttr{
+			{Name: string(jackett.AttrCategory), Value: strconv.Itoa(category)},
+			{Name: string(jackett.AttrSeeders), Value: strconv.Itoa(seeders)},
+			{Name: string(jackett.AttrPeers), Value: strconv.Itoa(seeders)},
+		},
+	}
+}
+
+// AddIndexer configures the indexer, replacing one with the same id, with
+// the items as its results.
+func (s *Server) AddIndexer(ind jackett.Indexer, items ...jackett.FeedItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.indexers {
+		if s.indexers[i].ID == ind.ID {
+			s.indexers[i] = ind
+			s.items[ind.ID] = s.tag(ind, items)
+			return
+		}
+	}
+
+	s.indexers = append(s.indexers, ind)
+	s.items[ind.ID] = s.tag(ind, items)
+}
+
+// SetResults replaces the results of the indexer.
+func (s *Server) SetResults(indexer string, items ...jackett.FeedItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ind, ok := s.indexer(indexer); ok {
+		s.items[indexer] = s.tag(ind, items)
+	}
+}
+
+// Fail makes the requests of the indexer, or of every indexer for "all",
+// fail with f until Recover.
+func (s *Server) Fail(indexer string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[indexer] = f
+}
+
+func (s *Server) Recover(indexer string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, indexer)
+}
+
+// SetLatency delays the responses of the indexer, or of every indexer for
+// "all", by d.
+func (s *Server) SetLatency(indexer string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency[indexer] = d
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// tag attributes the items to the indexer as Jackett does.
+func (s *Server) tag(ind jackett.Indexer, items []jackett.FeedItem) []jackett.FeedItem {
+	tagged := make([]jackett.FeedItem, len(items))
+	for i, item := range items {
+		item.Jackettindexer = jackett.JackettIndexer{Text: ind.Title, ID: ind.ID}
+		tagged[i] = item
+	}
+
+	return tagged
+}
+
+func (s *Server) indexer(id string) (jackett.Indexer, bool) {
+	for _, ind := range s.indexers {
+		if ind.ID == id {
+			return ind, true
+		}
+	}
+
+	return jackett.Indexer{}, false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v2.0/indexers/")
+	indexer, endpoint, _ := strings.Cut(rest, "/")
+	if rest == r.URL.Path || strings.TrimSuffix(endpoint, "/api") != "results/torznab" {
+		http.NotFound(w, r)
+		return
+	}
+
+	params := r.URL.Query()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Indexer: indexer, Params: params})
+	delay := s.latency[indexer] + s.latency["all"]
+	failure, failing := s.failures[indexer]
+	if !failing {
+		failure, failing = s.failures["all"]
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	key := params.Get("apikey")
+	if key == "" {
+		key = r.Header.Get("X-Api-Key")
+	}
+
+	if s.APIKey != "" && key != s.APIKey {
+		writeError(w, Failure{Status: http.StatusUnauthorized, Code: jackett.ErrCodeIncorrectCredentials, Description: "Invalid API Key"})
+		return
+	}
+
+	if failing {
+		writeError(w, failure)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ind, ok := s.indexer(indexer)
+	if !ok && indexer != "all" {
+		writeError(w, Failure{Status: http.StatusNotFound, Code: jackett.ErrCodeIncorrectParameter, Description: "Indexer is not configured"})
+		return
+	}
+
+	switch t := params.Get("t"); t {
+	case "indexers":
+		writeXML(w, "indexers", jackett.Indexers{Indexer: s.indexers})
+	case "caps":
+		if indexer == "all" {
+			ind = NewIndexer("all", "AggregateSearch")
+		}
+
+		writeXML(w, "caps", ind.Caps)
+	case "", "search", "tvsearch", "tv-search", "movie", "movie-search", "music", "audio", "book":
+		writeXML(w, "rss", s.search(indexer, params))
+	default:
+		writeError(w, Failure{Code: jackett.ErrCodeNoSuchFunction, Description: "Function Not Available: " + t})
+	}
+}
+
+// search returns the results of the indexer, or of every indexer for "all",
+// matching the params.
+func (s *Server) search(indexer string, params url.Values) jackett.Rss {
+	var candidates []jackett.FeedItem
+	if indexer == "all" {
+		for _, ind := range s.indexers {
+			candidates = append(candidates, s.items[ind.ID]...)
+		}
+	} else {
+		candidates = s.items[indexer]
+	}
+
+	query := strings.ToLower(params.Get("q"))
+
+	cats := make(map[string]bool)
+	for _, cat := range strings.Split(params.Get("cat"), ",") {
+		if cat != "" {
+			cats[cat] = true
+		}
+	}
+
+	var matched []jackett.FeedItem
+	for _, item := range candidates {
+		if !strings.Contains(strings.ToLower(item.Title), query) {
+			continue
+		}
+
+		if len(cats) > 0 && !inCategories(item, cats) {
+			continue
+		}
+
+		matched = append(matched, item)
+	}
+
+	if offset, _ := strconv.Atoi(params.Get("offset")); offset > 0 {
+		if offset > len(matched) {
+			offset = len(matched)
+		}
+
+		matched = matched[offset:]
+	}
+
+	if limit, _ := strconv.Atoi(params.Get("limit")); limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	return jackett.NewFeed(indexer, s.URL+"/api/v2.0/indexers/"+indexer+"/results/torznab/api", matched)
+}
+
+func inCategories(item jackett.FeedItem, cats map[string]bool) bool {
+	for _, cat := range item.Category {
+		if cats[cat] {
+			return true
+		}
+
+		// searching a parent category matches its subcategories
+		if n, err := strconv.Atoi(cat); err == nil && cats[strconv.Itoa(jackett.ParentCategory(n))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeXML writes v as the root element with the name.
+func writeXML(w http.ResponseWriter, name string, v interface{}) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&b).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if f.Code == 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	b, _ := xml.Marshal(struct {
+		XMLName     xml.Name `xml:"error"`
+		Code        int      `xml:"code,attr"`
+		Description string   `xml:"description,attr"`
+	}{Code: f.Code, Description: f.Description})
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
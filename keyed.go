@@ -0,0 +1,58 @@
+package jackett
+
+import (
+	"context"
+	"sync"
+)
+
+type keyedSearches struct {
+	mu      sync.Mutex
+	seq     uint64
+	running map[string]keyedSearch
+}
+
+type keyedSearch struct {
+	id     uint64
+	cancel context.CancelFunc
+}
+
+// start cancels the search running under key, if any, and registers a new one.
+func (k *keyedSearches) start(ctx context.Context, key string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.running == nil {
+		k.running = make(map[string]keyedSearch)
+	}
+
+	if prev, ok := k.running[key]; ok {
+		prev.cancel()
+	}
+
+	k.seq++
+	id := k.seq
+	k.running[key] = keyedSearch{id: id, cancel: cancel}
+
+	return ctx, func() {
+		cancel()
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		if cur, ok := k.running[key]; ok && cur.id == id {
+			delete(k.running, key)
+		}
+	}
+}
+
+// SearchKeyedCtx searches like GetTorrentsCtx, but cancels any search still
+// in flight that was started with the same key, e.g. the previous keystroke
+// of an interactive search box.
+func (c *Client) SearchKeyedCtx(ctx context.Context, key string, indexer string, opts map[string]string) (Rss, error) {
+	ctx, done := c.keyed.start(ctx, key)
+	defer done()
+
+	return c.GetTorrentsCtx(ctx, indexer, opts)
+}
@@ -0,0 +1,100 @@
+package jackett
+
+import (
+	"strings"
+)
+
+// languageCodes maps language names and ISO 639-2 codes as spelled by
+// indexers onto ISO 639-1 codes.
+var languageCodes = map[string]string{
+	"english": "en", "eng": "en",
+	"french": "fr", "fre": "fr", "fra": "fr", "francais": "fr", "français": "fr",
+	"german": "de", "ger": "de", "deu": "de", "deutsch": "de",
+	"spanish": "es", "spa": "es", "espanol": "es", "español": "es", "castellano": "es",
+	"italian": "it", "ita": "it", "italiano": "it",
+	"portuguese": "pt", "por": "pt", "portugues": "pt", "português": "pt", "brazilian": "pt-BR",
+	"dutch": "nl", "dut": "nl", "nld": "nl", "nederlands": "nl",
+	"russian": "ru", "rus": "ru",
+	"ukrainian": "uk", "ukr": "uk",
+	"polish": "pl", "pol": "pl", "polski": "pl",
+	"czech": "cs", "cze": "cs", "ces": "cs",
+	"hungarian": "hu", "hun": "hu", "magyar": "hu",
+	"romanian": "ro", "rum": "ro", "ron": "ro",
+	"swedish": "sv", "swe": "sv", "svenska": "sv",
+	"norwegian": "no", "nor": "no",
+	"danish": "da", "dan": "da",
+	"finnish": "fi", "fin": "fi",
+	"greek": "el", "gre": "el", "ell": "el",
+	"turkish": "tr", "tur": "tr",
+	"arabic": "ar", "ara": "ar",
+	"hebrew": "he", "heb": "he",
+	"hindi": "hi", "hin": "hi",
+	"japanese": "ja", "jpn": "ja",
+	"korean": "ko", "kor": "ko",
+	"chinese": "zh", "chi": "zh", "zho": "zh", "mandarin": "zh",
+	"thai": "th", "tha": "th",
+	"vietnamese": "vi", "vie": "vi",
+	"indonesian": "id", "ind": "id",
+	"bulgarian": "bg", "bul": "bg",
+	"croatian": "hr", "hrv": "hr",
+	"serbian": "sr", "srp": "sr",
+	"slovak": "sk", "slo": "sk", "slk": "sk",
+	"slovenian": "sl", "slv": "sl",
+	"estonian": "et", "est": "et",
+	"latvian": "lv", "lav": "lv",
+	"lithuanian": "lt", "lit": "lt",
+	"persian": "fa", "per": "fa", "fas": "fa", "farsi": "fa",
+	"catalan": "ca", "cat": "ca",
+}
+
+// NormalizeLanguage converts a language as spelled by an indexer, e.g.
+// "English", "eng", "en_us" or "pt-br", into a BCP-47 tag like "en",
+// "en-US" or "pt-BR". Unrecognized values are returned trimmed but otherwise
+// unchanged.
+func NormalizeLanguage(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return ""
+	}
+
+	if code, ok := languageCodes[strings.ToLower(lang)]; ok {
+		return code
+	}
+
+	parts := strings.FieldsFunc(lang, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+
+	primary := strings.ToLower(parts[0])
+	if code, ok := languageCodes[primary]; ok {
+		primary = code
+	} else if len(primary) != 2 && len(primary) != 3 {
+		return lang
+	}
+
+	tag := []string{primary}
+	for _, part := range parts[1:] {
+		switch len(part) {
+		case 2, 3:
+			// region, e.g. US or 419
+			tag = append(tag, strings.ToUpper(part))
+		case 4:
+			// script, e.g. Hant
+			tag = append(tag, strings.ToUpper(part[:1])+strings.ToLower(part[1:]))
+		default:
+			tag = append(tag, strings.ToLower(part))
+		}
+	}
+
+	return strings.Join(tag, "-")
+}
+
+// Language returns the normalized language of the channel.
+func (r Rss) Language() string {
+	return NormalizeLanguage(r.Channel.Language)
+}
+
+// Language returns the normalized language attribute of the item.
+func (i FeedItem) Language() string {
+	return NormalizeLanguage(i.GetAttr(AttrLanguage))
+}
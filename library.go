@@ -0,0 +1,22 @@
+package jackett
+
+// Library reports content that is already owned, e.g. from the database of a
+// Plex or Jellyfin server, so a Watcher skips new releases of it instead of
+// delivering them to rules and consumers.
+type Library interface {
+	// Owns reports whether the content of the release is owned. ids are
+	// empty when the indexer doesn't send them.
+	Owns(ids ExternalIDs, release Release) bool
+}
+
+// LibraryFunc adapts a function to a Library.
+type LibraryFunc func(ids ExternalIDs, release Release) bool
+
+func (f LibraryFunc) Owns(ids ExternalIDs, release Release) bool {
+	return f(ids, release)
+}
+
+// owned reports whether the item is owned according to lib.
+func owned(lib Library, item FeedItem) bool {
+	return lib != nil && lib.Owns(item.ExternalIDs(), ParseRelease(item.Title))
+}
@@ -2,17 +2,32 @@ package jackett
 
 import (
 	"context"
-	"encoding/xml"
 	"io"
+	"net/http"
+	"strings"
+	"time"
 
-	"github.com/autobrr/go-qbittorrent/errors"
+	"github.com/kylesanderson/go-jackett/internal/errors"
 )
 
+// Deprecated: use GetIndexersCtx.
 func (c *Client) GetIndexers() (Indexers, error) {
-	return c.GetIndexersCtx(context.Background())
+	ctx, cancel := c.baseContext()
+	defer cancel()
+
+	return c.GetIndexersCtx(ctx)
 }
 
+// GetIndexersCtx lists the configured indexers, in ModeProwlarr the enabled
+// indexers of Prowlarr.
 func (c *Client) GetIndexersCtx(ctx context.Context) (Indexers, error) {
+	switch c.cfg.Mode {
+	case ModeProwlarr:
+		return c.getProwlarrIndexers(ctx)
+	case ModeNZBHydra2:
+		return Indexers{}, errors.New("nzbhydra2 does not list its indexers, see GetHydraStatsCtx")
+	}
+
 	opts := map[string]string{
 		"t":          "indexers",
 		"configured": "true",
@@ -23,59 +38,165 @@ func (c *Client) GetIndexersCtx(ctx context.Context) (Indexers, error) {
 	}
 
 	var ind Indexers
-	resp, err := c.getCtx(ctx, "all/results/torznab/api", opts)
-	if err != nil {
-		return ind, errors.Wrap(err, "all endpoint error")
-	}
-
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return ind, err
-	}
-
-	err = xml.Unmarshal(bodyBytes, &ind)
+	err := c.getTorznabInto(ctx, "all", "", opts, &ind)
 	return ind, err
 }
 
+// Deprecated: use GetTorrentsCtx.
 func (c *Client) GetTorrents(indexer string, opts map[string]string) (Rss, error) {
-	return c.GetTorrentsCtx(context.Background(), indexer, opts)
+	ctx, cancel := c.baseContext()
+	defer cancel()
+
+	return c.GetTorrentsCtx(ctx, indexer, opts)
 }
 
 func (c *Client) GetTorrentsCtx(ctx context.Context, indexer string, opts map[string]string) (Rss, error) {
+	return c.getTorrentsCtx(ctx, indexer, "", opts)
+}
+
+// getTorrentsCtx is GetTorrentsCtx on path, see buildTorznabUrl.
+func (c *Client) getTorrentsCtx(ctx context.Context, indexer, path string, opts map[string]string) (Rss, error) {
+	// copy the options so concurrent searches can share the caller's map
+	params := make(map[string]string, len(opts)+1)
+	for k, v := range opts {
+		params[k] = v
+	}
+
 	if len(c.cfg.APIKey) != 0 {
-		opts["apikey"] = c.cfg.APIKey
+		params["apikey"] = c.cfg.APIKey
 	}
 
-	var rss Rss
-	resp, err := c.getCtx(ctx, indexer+"/results/torznab/api", opts)
-	if err != nil {
-		return rss, errors.Wrap(err, indexer+" endpoint error")
+	key := cacheKey(indexer, params)
+	if path != "" {
+		// the same search on another path of a split api
+		key = path + " " + key
 	}
+	if c.cacheTTL > 0 {
+		if rss, ok := c.cachedResults(key); ok {
+			c.annotate(rss.Channel.Item)
+			c.checkAttrs(indexer, rss.Channel.Item)
+			c.cfg.Events.Search(indexer, params, len(rss.Channel.Item), nil)
+			return rss, nil
+		}
+	}
+
+	search := func(ctx context.Context) (Rss, error) {
+		var rss Rss
+		err := c.getTorznabInto(ctx, indexer, path, params, &rss)
+		return rss, err
+	}
+
+	var (
+		rss Rss
+		err error
+	)
 
-	defer resp.Body.Close()
+	if c.cfg.CoalesceWindow > 0 {
+		rss, err = c.coalesced.do(ctx, key, c.clock, time.Duration(c.cfg.CoalesceWindow)*time.Second, c.Timeout(), search)
+	} else {
+		rss, err = search(ctx)
+	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
+		c.cfg.Events.Search(indexer, params, 0, err)
 		return rss, err
 	}
 
-	err = xml.Unmarshal(bodyBytes, &rss)
-	return rss, err
+	if c.cacheTTL > 0 {
+		c.cacheResults(key, rss)
+	}
+
+	c.annotate(rss.Channel.Item)
+	c.checkAttrs(indexer, rss.Channel.Item)
+	c.cfg.Events.Search(indexer, params, len(rss.Channel.Item), nil)
+
+	return rss, nil
 }
 
+// Deprecated: use GetEnclosureCtx.
 func (c *Client) GetEnclosure(enclosure string) ([]byte, error) {
-	return c.GetEnclosureCtx(context.Background(), enclosure)
+	ctx, cancel := c.baseContext()
+	defer cancel()
+
+	return c.GetEnclosureCtx(ctx, enclosure)
 }
 
-func (c *Client) GetEnclosureCtx(ctx context.Context, enclosure string) ([]byte, error) {
+func (c *Client) GetEnclosureCtx(ctx context.Context, enclosure string) (b []byte, err error) {
+	indexer := enclosureIndexer(enclosure)
+
+	ctx, sp := c.startSpan(ctx, "enclosure", indexer)
+	defer func() { sp.end(err) }()
+
+	if err := c.waitRateLimit(ctx, indexer); err != nil {
+		return nil, err
+	}
+
+	if enclosure, err = c.EnclosureURL(enclosure); err != nil {
+		return nil, err
+	}
+
 	resp, err := c.getRawCtx(ctx, enclosure)
 	if err != nil {
-		return nil, errors.Wrap(err, enclosure)
+		return nil, errors.Wrap(err, "%v", c.redact(enclosure))
 	}
 
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
 	return io.ReadAll(resp.Body)
 }
+
+// ReportGrabCtx requests the Jackett proxied download link of an item and
+// discards the response, so the grab is counted by Jackett and the tracker
+// even when the item is downloaded through its magnet or infohash instead.
+func (c *Client) ReportGrabCtx(ctx context.Context, item FeedItem) error {
+	link := item.Enclosure.URL
+	if link == "" || strings.HasPrefix(link, "magnet:") {
+		link = item.Link
+	}
+
+	if link == "" || strings.HasPrefix(link, "magnet:") {
+		return errors.New("no download link to report grab for: %v", item.Title)
+	}
+
+	link, err := c.EnclosureURL(link)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not build request")
+	}
+
+	// the link may point at the tracker, only Jackett gets the credentials
+	if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" && c.isHost(req.URL) {
+		req.SetBasicAuth(c.cfg.BasicUser, c.cfg.BasicPass)
+	}
+
+	// jackett redirects magnet links, which can't and needn't be followed
+	client := *c.http
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := c.do(&client, req)
+	if err != nil {
+		return errors.Wrap(err, "error reporting grab: %v", c.redact(link))
+	}
+
+	drainAndClose(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return errors.Wrap(&StatusError{Status: resp.StatusCode}, "error reporting grab")
+	}
+
+	c.cfg.Events.Grab(item.Indexer(), item)
+
+	if c.cfg.Notifier != nil {
+		if err := c.cfg.Notifier.Notify(ctx, Notification{Kind: EventGrab, Source: item.Indexer(), Item: item}); err != nil {
+			c.log.Warn("notification failed", "err", err)
+		}
+	}
+
+	return nil
+}
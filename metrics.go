@@ -0,0 +1,194 @@
+package jackett
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder observes every request of a client once it completed,
+// retries included.
+type MetricsRecorder interface {
+	ObserveRequest(m RequestMetric)
+}
+
+type RequestMetric struct {
+	Indexer string
+
+	// search, caps, indexers or enclosure
+	Endpoint string
+
+	// 0 when no response was received
+	Status int
+
+	Duration time.Duration
+	Err      error
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency
+// histogram of PrometheusMetrics.
+var DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// PrometheusMetrics counts requests, errors and latency per indexer and
+// endpoint, and serves them in the Prometheus text format. It can be shared
+// by several clients.
+type PrometheusMetrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	endpoints map[endpointLabels]*endpointStats
+}
+
+type endpointLabels struct {
+	indexer  string
+	endpoint string
+}
+
+type requestLabels struct {
+	endpointLabels
+	status int
+}
+
+type endpointStats struct {
+	errors uint64
+
+	// latency histogram
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics returns metrics with the given latency buckets in
+// seconds, DefaultLatencyBuckets when none are given.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:   buckets,
+		requests:  make(map[requestLabels]uint64),
+		endpoints: make(map[endpointLabels]*endpointStats),
+	}
+}
+
+func (p *PrometheusMetrics) ObserveRequest(m RequestMetric) {
+	labels := endpointLabels{indexer: m.Indexer, endpoint: m.Endpoint}
+	seconds := m.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[requestLabels{endpointLabels: labels, status: m.Status}]++
+
+	s, ok := p.endpoints[labels]
+	if !ok {
+		s = &endpointStats{counts: make([]uint64, len(p.buckets))}
+		p.endpoints[labels] = s
+	}
+
+	if m.Err != nil {
+		s.errors++
+	}
+
+	for i, le := range p.buckets {
+		if seconds <= le {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.sum += seconds
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	p.mu.Lock()
+
+	b.WriteString("# HELP jackett_requests_total Requests by indexer, endpoint and status, 0 when no response was received.\n")
+	b.WriteString("# TYPE jackett_requests_total counter\n")
+	for _, l := range sortedRequestLabels(p.requests) {
+		fmt.Fprintf(&b, "jackett_requests_total{indexer=%q,endpoint=%q,status=\"%d\"} %d\n", l.indexer, l.endpoint, l.status, p.requests[l])
+	}
+
+	b.WriteString("# HELP jackett_request_errors_total Failed requests by indexer and endpoint.\n")
+	b.WriteString("# TYPE jackett_request_errors_total counter\n")
+	endpoints := p.sortedEndpoints()
+	for _, l := range endpoints {
+		fmt.Fprintf(&b, "jackett_request_errors_total{indexer=%q,endpoint=%q} %d\n", l.indexer, l.endpoint, p.endpoints[l].errors)
+	}
+
+	b.WriteString("# HELP jackett_request_duration_seconds Request latency by indexer and endpoint, retries included.\n")
+	b.WriteString("# TYPE jackett_request_duration_seconds histogram\n")
+	for _, l := range endpoints {
+		s := p.endpoints[l]
+		for i, le := range p.buckets {
+			fmt.Fprintf(&b, "jackett_request_duration_seconds_bucket{indexer=%q,endpoint=%q,le=%q} %d\n",
+				l.indexer, l.endpoint, strconv.FormatFloat(le, 'g', -1, 64), s.counts[i])
+		}
+
+		fmt.Fprintf(&b, "jackett_request_duration_seconds_bucket{indexer=%q,endpoint=%q,le=\"+Inf\"} %d\n", l.indexer, l.endpoint, s.count)
+		fmt.Fprintf(&b, "jackett_request_duration_seconds_sum{indexer=%q,endpoint=%q} %g\n", l.indexer, l.endpoint, s.sum)
+		fmt.Fprintf(&b, "jackett_request_duration_seconds_count{indexer=%q,endpoint=%q} %d\n", l.indexer, l.endpoint, s.count)
+	}
+
+	p.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics, e.g. as the /metrics endpoint.
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = p.WriteTo(w)
+}
+
+func sortedRequestLabels(m map[requestLabels]uint64) []requestLabels {
+	out := make([]requestLabels, 0, len(m))
+	for l := range m {
+		out = append(out, l)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].endpointLabels != out[j].endpointLabels {
+			return out[i].endpointLabels.less(out[j].endpointLabels)
+		}
+
+		return out[i].status < out[j].status
+	})
+
+	return out
+}
+
+func (p *PrometheusMetrics) sortedEndpoints() []endpointLabels {
+	out := make([]endpointLabels, 0, len(p.endpoints))
+	for l := range p.endpoints {
+		out = append(out, l)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].less(out[j])
+	})
+
+	return out
+}
+
+func (l endpointLabels) less(o endpointLabels) bool {
+	if l.indexer != o.indexer {
+		return l.indexer < o.indexer
+	}
+
+	return l.endpoint < o.endpoint
+}
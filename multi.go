@@ -0,0 +1,372 @@
+package jackett
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// MultiClient aggregates searches over every configured indexer of one or
+// more clients, routing each search to the indexers that can serve it.
+type MultiClient struct {
+	clients []*Client
+
+	mu       sync.RWMutex
+	indexers map[*Client][]Indexer
+	caps     CapsMatrix
+
+	// closed once a running prefetch completes
+	prefetched chan struct{}
+}
+
+type MultiResult struct {
+	// results of the same torrent, by info hash or guid, from several
+	// indexers or clients are merged into the one with the most seeders
+	Items []FeedItem
+
+	// errors keyed by the host of the client and the indexer id, e.g.
+	// http://127.0.0.1:9117/tracker, so the same indexer failing on two
+	// clients is reported for each
+	Errors map[string]error
+}
+
+// errorKey returns the key of the errors of an indexer in MultiResult.
+func errorKey(host, indexer string) string {
+	return strings.TrimSuffix(host, "/") + "/" + indexer
+}
+
+// mergeItems merges the items of the same torrent, by info hash or guid,
+// keeping the one with the most seeders. Items without either are kept.
+func mergeItems(items []FeedItem) []FeedItem {
+	var (
+		merged []FeedItem
+		seen   = make(map[string]int)
+	)
+
+	for _, item := range items {
+		key := ""
+		if hash := item.InfoHash(); hash != "" {
+			key = "btih:" + hash
+		} else if item.Guid != "" {
+			key = "guid:" + item.Guid
+		}
+
+		if key == "" {
+			merged = append(merged, item)
+			continue
+		}
+
+		if i, ok := seen[key]; ok {
+			if item.Seeders() > merged[i].Seeders() {
+				merged[i] = item
+			}
+
+			continue
+		}
+
+		seen[key] = len(merged)
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+func NewMultiClient(clients ...*Client) *MultiClient {
+	return &MultiClient{
+		clients: clients,
+	}
+}
+
+// RefreshIndexers fetches the configured indexers of every client.
+func (m *MultiClient) RefreshIndexers(ctx context.Context) error {
+	indexers := make(map[*Client][]Indexer, len(m.clients))
+	for _, c := range m.clients {
+		ind, err := c.GetIndexersCtx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "could not get indexers for %v", c.cfg.Host)
+		}
+
+		indexers[c] = ind.Indexer
+	}
+
+	m.mu.Lock()
+	m.indexers = indexers
+	m.mu.Unlock()
+
+	return nil
+}
+
+// InstanceIndexer is an indexer of one of the clients of a MultiClient.
+type InstanceIndexer struct {
+	Indexer
+
+	// the client configuring the indexer, and its host
+	Client *Client
+	Host   string
+}
+
+type MultiIndexers struct {
+	// in the order of the clients
+	Indexers []InstanceIndexer
+
+	// hosts of the indexers configured on more than one client, keyed by
+	// indexer id. SearchCtx searches them on each of those clients.
+	Duplicates map[string][]string
+}
+
+// GetIndexersCtx refreshes and merges the configured indexers of every
+// client, attributing each to the client it is configured on.
+func (m *MultiClient) GetIndexersCtx(ctx context.Context) (MultiIndexers, error) {
+	result := MultiIndexers{Duplicates: make(map[string][]string)}
+
+	if err := m.RefreshIndexers(ctx); err != nil {
+		return result, err
+	}
+
+	m.mu.RLock()
+	indexers := m.indexers
+	m.mu.RUnlock()
+
+	hosts := make(map[string][]string)
+	for _, c := range m.clients {
+		for _, ind := range indexers[c] {
+			result.Indexers = append(result.Indexers, InstanceIndexer{Indexer: ind, Client: c, Host: c.cfg.Host})
+			hosts[ind.ID] = append(hosts[ind.ID], c.cfg.Host)
+		}
+	}
+
+	for id, h := range hosts {
+		if len(h) > 1 {
+			result.Duplicates[id] = h
+		}
+	}
+
+	return result, nil
+}
+
+// Prefetch loads the indexers and their caps in the background, bounded by
+// timeout, so the first search is not penalized by capability discovery.
+// Searches issued meanwhile wait for the prefetch. The returned channel
+// receives its result.
+func (m *MultiClient) Prefetch(timeout time.Duration) <-chan error {
+	done := make(chan struct{})
+	result := make(chan error, 1)
+
+	m.mu.Lock()
+	m.prefetched = done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := m.RefreshIndexers(ctx); err != nil {
+			result <- err
+			return
+		}
+
+		// searches waiting for the prefetch are routed by the caps, so
+		// discover them here rather than through DiscoverCaps, which
+		// would wait for the prefetch itself
+		m.mu.RLock()
+		indexers := m.indexers
+		m.mu.RUnlock()
+
+		_, err := m.discoverCaps(ctx, indexers)
+		result <- err
+	}()
+
+	return result
+}
+
+func (m *MultiClient) loadIndexers(ctx context.Context) (map[*Client][]Indexer, error) {
+	m.mu.RLock()
+	prefetched := m.prefetched
+	m.mu.RUnlock()
+
+	if prefetched != nil {
+		select {
+		case <-prefetched:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	m.mu.RLock()
+	indexers := m.indexers
+	m.mu.RUnlock()
+
+	if indexers != nil {
+		return indexers, nil
+	}
+
+	if err := m.RefreshIndexers(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.indexers, nil
+}
+
+// SearchCtx searches all indexers supporting the categories in opts["cat"]
+// concurrently and merges their results. Every indexer is searched when no
+// categories are given. Id params are only sent to indexers whose caps
+// support them, the others are searched by the text query, or the title,
+// instead, or skipped when there is neither.
+func (m *MultiClient) SearchCtx(ctx context.Context, opts map[string]string) (MultiResult, error) {
+	result := MultiResult{Errors: make(map[string]error)}
+
+	indexers, err := m.loadIndexers(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	type capsKey struct {
+		client  *Client
+		indexer string
+	}
+
+	m.mu.RLock()
+	discovered := make(map[capsKey]IndexerCapabilities, len(m.caps))
+	for _, ic := range m.caps {
+		discovered[capsKey{ic.client, ic.Indexer}] = ic
+	}
+	m.mu.RUnlock()
+
+	cats := parseCategories(opts["cat"])
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for c, list := range indexers {
+		for _, ind := range list {
+			if !ind.SupportsAnyCategory(cats) {
+				continue
+			}
+
+			ic, ok := discovered[capsKey{c, ind.ID}]
+			if !ok {
+				ic = ind.Capabilities()
+			}
+
+			params, ok := routeParams(ic, opts)
+			if !ok {
+				continue
+			}
+
+			wg.Add(1)
+			go func(c *Client, id string, params map[string]string) {
+				defer wg.Done()
+
+				rss, err := c.GetTorrentsCtx(ctx, id, params)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					result.Errors[errorKey(c.cfg.Host, id)] = err
+					return
+				}
+
+				result.Items = append(result.Items, rss.Channel.Item...)
+			}(c, ind.ID, params)
+		}
+	}
+
+	wg.Wait()
+
+	result.Items = mergeItems(result.Items)
+	return result, nil
+}
+
+// searchModes maps the torznab t param onto the search modes of the caps.
+var searchModes = map[string]string{
+	SearchTypeSearch: SearchModeSearch,
+	SearchTypeTV:     SearchModeTV,
+	SearchTypeMovie:  SearchModeMovie,
+	SearchTypeMusic:  SearchModeMusic,
+	SearchTypeAudio:  SearchModeAudio,
+	SearchTypeBook:   SearchModeBook,
+}
+
+// routeParams drops the id params the indexer doesn't support, downgrading
+// to a text search when its caps don't offer the search mode or none of the
+// ids are left. The text search is by the q param, or the title param when
+// there is none, and drops the params plain searches of the indexer don't
+// support, e.g. season and ep. It reports false when nothing meaningful is
+// left to search.
+func routeParams(ic IndexerCapabilities, opts map[string]string) (map[string]string, bool) {
+	mode, ok := searchModes[opts["t"]]
+	if !ok || len(ic.Modes) == 0 {
+		// unknown mode or caps, send as is
+		return opts, true
+	}
+
+	params := make(map[string]string, len(opts))
+	for k, v := range opts {
+		params[k] = v
+	}
+
+	hadIDs, keptIDs := false, false
+	for _, p := range idParams {
+		if params[p] == "" {
+			continue
+		}
+
+		hadIDs = true
+		if ic.SupportsMode(mode) && ic.Supports(mode, p) {
+			keptIDs = true
+			continue
+		}
+
+		delete(params, p)
+	}
+
+	if ic.SupportsMode(mode) && (keptIDs || !hadIDs) {
+		return params, true
+	}
+
+	query := params["q"]
+	if query == "" {
+		query = params["title"]
+	}
+
+	if query == "" {
+		return nil, false
+	}
+
+	// downgrade to a plain text search
+	for _, p := range searchParams {
+		if !ic.Supports(SearchModeSearch, p) {
+			delete(params, p)
+		}
+	}
+
+	params["t"] = "search"
+	params["q"] = query
+	return params, true
+}
+
+func parseCategories(s string) []int {
+	var cats []int
+	for _, field := range strings.Split(s, ",") {
+		cat, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			continue
+		}
+
+		cats = append(cats, cat)
+	}
+
+	return cats
+}
@@ -0,0 +1,98 @@
+package jackett_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+)
+
+// requestTypes returns the t param of every request received by srv.
+func requestTypes(srv *jackettest.Server) []string {
+	var types []string
+	for _, r := range srv.Requests() {
+		types = append(types, r.Params.Get("t"))
+	}
+
+	return types
+}
+
+func TestPrefetchDiscoversCaps(t *testing.T) {
+	srv := jackettest.NewServer("apikey")
+	defer srv.Close()
+
+	srv.AddIndexer(jackettest.NewIndexer("movies", "Movies", 2000),
+		jackettest.Item("Movie 2024 1080p", 2000, 10))
+	srv.AddIndexer(jackettest.NewIndexer("tv", "TV", 5000),
+		jackettest.Item("Show S01E01 1080p", 5000, 10))
+
+	multi := jackett.NewMultiClient(srv.Client())
+
+	select {
+	case err := <-multi.Prefetch(time.Second):
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("prefetch did not complete")
+	}
+
+	assert.ElementsMatch(t, []string{"indexers", "caps", "caps"}, requestTypes(srv))
+
+	// searches are routed by the prefetched caps without fetching any
+	result, err := multi.SearchCtx(context.Background(), map[string]string{"t": "search", "q": "1080p"})
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 2)
+	assert.ElementsMatch(t, []string{"indexers", "caps", "caps", "search", "search"}, requestTypes(srv))
+
+	caps, err := multi.DiscoverCaps(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, caps, 2)
+}
+
+func TestMultiClientSearchAcrossClients(t *testing.T) {
+	first := jackettest.NewServer("apikey")
+	defer first.Close()
+
+	second := jackettest.NewServer("apikey")
+	defer second.Close()
+
+	// the same torrent on both, better seeded on the second
+	first.AddIndexer(jackettest.NewIndexer("public", "Public", 2000),
+		jackettest.Item("Movie 2024 1080p", 2000, 5),
+		jackettest.Item("Movie 2024 720p", 2000, 3))
+	second.AddIndexer(jackettest.NewIndexer("public", "Public", 2000),
+		jackettest.Item("Movie 2024 1080p", 2000, 50))
+
+	// the same indexer failing on both
+	for _, srv := range []*jackettest.Server{first, second} {
+		srv.AddIndexer(jackettest.NewIndexer("private", "Private", 2000))
+	}
+
+	multi := jackett.NewMultiClient(first.Client(), second.Client())
+	_, err := multi.GetIndexersCtx(context.Background())
+	require.NoError(t, err)
+
+	for _, srv := range []*jackettest.Server{first, second} {
+		srv.Fail("private", jackettest.Failure{Code: jackett.ErrCodeIncorrectCredentials, Description: "Login failed"})
+	}
+
+	result, err := multi.SearchCtx(context.Background(), map[string]string{"t": "search", "q": "movie"})
+	require.NoError(t, err)
+
+	require.Len(t, result.Items, 2)
+
+	seeders := make(map[string]int)
+	for _, item := range result.Items {
+		seeders[item.Title] = item.Seeders()
+	}
+
+	assert.Equal(t, map[string]int{"Movie 2024 1080p": 50, "Movie 2024 720p": 3}, seeders)
+
+	assert.Len(t, result.Errors, 2)
+	assert.Contains(t, result.Errors, first.URL+"/private")
+	assert.Contains(t, result.Errors, second.URL+"/private")
+}
@@ -0,0 +1,138 @@
+package jackett
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Phases of a request a network error can occur in.
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "connect"
+	PhaseTLS     = "tls"
+	PhaseRead    = "read"
+)
+
+// NetworkError is returned for requests that failed before a response was
+// received, tagged with the phase they failed in, so a DNS problem can be
+// told apart from an overloaded tracker.
+type NetworkError struct {
+	phase string
+	Err   error
+}
+
+func (e *NetworkError) Error() string {
+	return e.phase + ": " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Phase returns one of PhaseDNS, PhaseConnect, PhaseTLS or PhaseRead.
+func (e *NetworkError) Phase() string {
+	return e.phase
+}
+
+func (e *NetworkError) Timeout() bool {
+	var ne net.Error
+	return errors.As(e.Err, &ne) && ne.Timeout()
+}
+
+// phaseTracker follows a request through its phases using httptrace.
+type phaseTracker struct {
+	mu    sync.Mutex
+	phase string
+	https bool
+}
+
+func newPhaseTracker(https bool) *phaseTracker {
+	return &phaseTracker{phase: PhaseDNS, https: https}
+}
+
+func (p *phaseTracker) set(phase string) {
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+}
+
+func (p *phaseTracker) get() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.phase
+}
+
+func (p *phaseTracker) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			p.set(PhaseConnect)
+		},
+		ConnectStart: func(string, string) {
+			p.set(PhaseConnect)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil && p.https {
+				p.set(PhaseTLS)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				p.set(PhaseRead)
+			}
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			p.set(PhaseRead)
+		},
+	}
+}
+
+// wrapNetworkError tags err with the phase it occurred in, preferring what
+// the error itself tells over how far the trace got. Errors of a canceled or
+// expired ctx, the one of the caller, are returned as is, they say nothing
+// about the host. Expiry of the timeout of the client does, it is tagged as
+// a connect or read timeout by how far the request got.
+func wrapNetworkError(ctx context.Context, err error, tracked string) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		phase := PhaseRead
+		if tracked != PhaseRead {
+			phase = PhaseConnect
+		}
+
+		return &NetworkError{phase: phase, Err: err}
+	}
+
+	phase := tracked
+
+	var (
+		dnsErr    *net.DNSError
+		opErr     *net.OpError
+		recordErr tls.RecordHeaderError
+		authErr   x509.UnknownAuthorityError
+		certErr   x509.CertificateInvalidError
+		hostErr   x509.HostnameError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		phase = PhaseDNS
+	case errors.As(err, &recordErr), errors.As(err, &authErr), errors.As(err, &certErr),
+		errors.As(err, &hostErr), strings.Contains(err.Error(), "tls: "):
+		phase = PhaseTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		phase = PhaseConnect
+	}
+
+	return &NetworkError{phase: phase, Err: err}
+}
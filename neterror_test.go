@@ -0,0 +1,107 @@
+package jackett_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// newStallingServer accepts requests but doesn't answer them until the
+// request is gone.
+func newStallingServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// stallingDial never connects.
+func stallingDial(ctx context.Context, _, _ string) (net.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNetworkErrorTimeout(t *testing.T) {
+	tests := []struct {
+		name  string
+		dial  func(ctx context.Context, network, addr string) (net.Conn, error)
+		phase string
+	}{
+		{
+			name:  "read",
+			phase: jackett.PhaseRead,
+		},
+		{
+			name:  "connect",
+			dial:  stallingDial,
+			phase: jackett.PhaseConnect,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			if tt.dial != nil {
+				transport.DialContext = tt.dial
+			}
+
+			client := jackett.NewClient(jackett.Config{
+				Host:         newStallingServer(t).URL,
+				APIKey:       "apikey",
+				Transport:    transport,
+				DisableRetry: true,
+			})
+			client.SetTimeout(50 * time.Millisecond)
+
+			// the timeout of the client expires, not the one of the caller
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := client.GetIndexersCtx(ctx)
+			require.Error(t, err)
+
+			var nerr *jackett.NetworkError
+			require.True(t, errors.As(err, &nerr), err)
+			assert.Equal(t, tt.phase, nerr.Phase())
+			assert.True(t, nerr.Timeout())
+		})
+	}
+}
+
+func TestNetworkErrorCallerDeadline(t *testing.T) {
+	for _, dial := range []func(context.Context, string, string) (net.Conn, error){nil, stallingDial} {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if dial != nil {
+			transport.DialContext = dial
+		}
+
+		client := jackett.NewClient(jackett.Config{
+			Host:         newStallingServer(t).URL,
+			APIKey:       "apikey",
+			Transport:    transport,
+			DisableRetry: true,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := client.GetIndexersCtx(ctx)
+		cancel()
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// says nothing about the host
+		var nerr *jackett.NetworkError
+		assert.False(t, errors.As(err, &nerr), err)
+	}
+}
@@ -0,0 +1,151 @@
+package jackett
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// NZBContentType is the enclosure type of usenet releases.
+const NZBContentType = "application/x-nzb"
+
+// IsNZB reports whether the item is a usenet release of a newznab indexer.
+func (i FeedItem) IsNZB() bool {
+	return downloadType(i.Enclosure.URL, i.Enclosure.Type) == DownloadNZB
+}
+
+// GrabCount returns the number of times the release was downloaded.
+func (i FeedItem) GrabCount() int {
+	if n, err := strconv.Atoi(i.GetAttr(AttrGrabs)); err == nil {
+		return n
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSpace(i.Grabs))
+	return n
+}
+
+// Poster returns who posted the usenet release.
+func (i FeedItem) Poster() string {
+	return i.GetAttr(AttrPoster)
+}
+
+// Groups returns the newsgroups the usenet release was posted to.
+func (i FeedItem) Groups() []string {
+	var groups []string
+	for _, attr := range i.Attr {
+		if !strings.EqualFold(attr.Name, string(AttrGroup)) {
+			continue
+		}
+
+		for _, g := range strings.Split(attr.Value, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+	}
+
+	return groups
+}
+
+// UsenetDate returns when the usenet release was posted, the zero time when
+// the indexer doesn't say.
+func (i FeedItem) UsenetDate() time.Time {
+	s := strings.TrimSpace(i.GetAttr(AttrUsenetDate))
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// Passworded reports whether the archives of the usenet release are password
+// protected, or contain other archives that might be.
+func (i FeedItem) Passworded() bool {
+	p := i.GetAttr(AttrPassword)
+	return p != "" && p != "0"
+}
+
+// NZB is a parsed nzb file.
+type NZB struct {
+	XMLName xml.Name  `xml:"nzb"`
+	Meta    []NZBMeta `xml:"head>meta"`
+	Files   []NZBFile `xml:"file"`
+
+	// the file as downloaded
+	Raw []byte `xml:"-"`
+}
+
+type NZBMeta struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type NZBFile struct {
+	Poster   string       `xml:"poster,attr"`
+	Date     int64        `xml:"date,attr"`
+	Subject  string       `xml:"subject,attr"`
+	Groups   []string     `xml:"groups>group"`
+	Segments []NZBSegment `xml:"segments>segment"`
+}
+
+type NZBSegment struct {
+	Bytes     int64  `xml:"bytes,attr"`
+	Number    int    `xml:"number,attr"`
+	MessageID string `xml:",chardata"`
+}
+
+// Size returns the sum of the segment sizes.
+func (n *NZB) Size() int64 {
+	var size int64
+	for _, f := range n.Files {
+		for _, s := range f.Segments {
+			size += s.Bytes
+		}
+	}
+
+	return size
+}
+
+// GetMeta returns the value of the head meta of the type, e.g. "password".
+func (n *NZB) GetMeta(typ string) string {
+	for _, m := range n.Meta {
+		if strings.EqualFold(m.Type, typ) {
+			return m.Value
+		}
+	}
+
+	return ""
+}
+
+// GetNZBCtx downloads and parses the nzb file of a usenet release. Responses
+// that aren't nzb files, e.g. the error pages of indexers over their api
+// limit, fail with a ParseError.
+func (c *Client) GetNZBCtx(ctx context.Context, enclosure string) (*NZB, error) {
+	b, err := c.GetEnclosureCtx(ctx, enclosure)
+	if err != nil {
+		return nil, err
+	}
+
+	if terr := parseTorznabError(b, 200); terr != nil {
+		return nil, terr
+	}
+
+	nzb := &NZB{Raw: b}
+	if err := xml.NewDecoder(bytes.NewReader(b)).Decode(nzb); err != nil {
+		if err == io.EOF {
+			err = errors.New("not an nzb file")
+		}
+
+		return nil, &ParseError{Format: "nzb", Body: truncateBody(b), Err: err}
+	}
+
+	return nzb, nil
+}
@@ -0,0 +1,203 @@
+package jackett
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// NotifyTimeout bounds the requests of the notifiers without a client.
+var NotifyTimeout = 10 * time.Second
+
+// Notification is sent to a Notifier on matches, grabs and errors. Kind is
+// one of EventMatch, EventGrab or EventError.
+type Notification struct {
+	Kind   string
+	Source string
+	Item   FeedItem
+	Err    error
+
+	// rule that matched the item, if any
+	Rule string
+}
+
+// Text renders the notification as a single line message.
+func (n Notification) Text() string {
+	switch n.Kind {
+	case EventMatch:
+		return "New release on " + n.Source + ": " + n.Item.Title
+	case EventGrab:
+		return "Grabbed from " + n.Source + ": " + n.Item.Title
+	case EventError:
+		if n.Err != nil {
+			return "Error on " + n.Source + ": " + n.Err.Error()
+		}
+	}
+
+	return n.Kind + " on " + n.Source
+}
+
+// Notifier delivers notifications, e.g. to a chat or webhook.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifyHandler returns a rule handler sending matches to n, for RuleNotify.
+func NotifyHandler(n Notifier) RuleHandler {
+	return func(ctx context.Context, rule Rule, feed string, item FeedItem) error {
+		return n.Notify(ctx, Notification{Kind: EventMatch, Source: feed, Item: item, Rule: rule.Name})
+	}
+}
+
+// WebhookNotifier posts notifications as JSON to URL.
+type WebhookNotifier struct {
+	URL string
+
+	// defaults to a client bounded by NotifyTimeout
+	Client *http.Client
+}
+
+type webhookNotification struct {
+	Kind    string `json:"kind"`
+	Source  string `json:"source"`
+	Text    string `json:"text"`
+	Rule    string `json:"rule,omitempty"`
+	Title   string `json:"title,omitempty"`
+	GUID    string `json:"guid,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Indexer string `json:"indexer,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Seeders int    `json:"seeders,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (w WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	payload := webhookNotification{
+		Kind:    n.Kind,
+		Source:  n.Source,
+		Text:    n.Text(),
+		Rule:    n.Rule,
+		Title:   n.Item.Title,
+		GUID:    n.Item.Guid,
+		Link:    n.Item.Enclosure.URL,
+		Indexer: n.Item.Indexer(),
+		Size:    n.Item.SizeBytes(),
+		Seeders: n.Item.Seeders(),
+	}
+
+	if n.Err != nil {
+		payload.Error = n.Err.Error()
+	}
+
+	return postJSON(ctx, w.Client, w.URL, payload)
+}
+
+// DiscordNotifier posts notifications to a Discord webhook, or any service
+// accepting Discord compatible payloads such as Slack's /slack endpoints.
+type DiscordNotifier struct {
+	WebhookURL string
+	Username   string
+
+	// defaults to a client bounded by NotifyTimeout
+	Client *http.Client
+}
+
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (d DiscordNotifier) Notify(ctx context.Context, n Notification) error {
+	msg := discordMessage{Username: d.Username}
+
+	if n.Kind == EventError {
+		msg.Content = n.Text()
+	} else {
+		msg.Embeds = []discordEmbed{{
+			Title:       n.Item.Title,
+			URL:         n.Item.Comments,
+			Description: n.Text(),
+		}}
+	}
+
+	return postJSON(ctx, d.Client, d.WebhookURL, msg)
+}
+
+// TelegramNotifier sends notifications through a Telegram bot.
+type TelegramNotifier struct {
+	Token  string
+	ChatID string
+
+	// defaults to https://api.telegram.org
+	BaseURL string
+
+	// defaults to a client bounded by NotifyTimeout
+	Client *http.Client
+}
+
+func (t TelegramNotifier) Notify(ctx context.Context, n Notification) error {
+	base := t.BaseURL
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+
+	endpoint, err := url.JoinPath(base, "bot"+t.Token, "sendMessage")
+	if err != nil {
+		return errors.Wrap(err, "invalid telegram url")
+	}
+
+	return postJSON(ctx, t.Client, endpoint, map[string]interface{}{
+		"chat_id":                  t.ChatID,
+		"text":                     n.Text(),
+		"disable_web_page_preview": true,
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, v interface{}) error {
+	if client == nil {
+		client = &http.Client{Timeout: NotifyTimeout}
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "could not encode notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "could not build request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// the url of telegram requests carries the bot token
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+
+		return errors.Wrap(err, "error sending notification")
+	}
+
+	drainAndClose(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return errors.New("unexpected notification status: %v", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package jackett
+
+import (
+	"context"
+	"strconv"
+)
+
+// DefaultPageLimit is the page size of SearchPagesCtx when neither the
+// search nor the caps of the indexer give one.
+var DefaultPageLimit = 100
+
+// SearchPagesCtx searches like GetTorrentsCtx, requesting page after page by
+// raising the offset, and calls fn with the items of every page until fn
+// returns false. It stops when a page holds fewer items than the page size,
+// when maxResults items were passed to fn, or when the server ignores the
+// offset and repeats a page. maxResults 0 is unbounded.
+//
+// The page size is the limit of opts, or the max limit of the caps of the
+// indexer, and never more than the max limit.
+func (c *Client) SearchPagesCtx(ctx context.Context, indexer string, opts map[string]string, maxResults int, fn func(items []FeedItem) bool) error {
+	limit, _ := strconv.Atoi(opts["limit"])
+	offset, _ := strconv.Atoi(opts["offset"])
+
+	// without caps the requested or default limit is used as is
+	if caps, err := c.GetCapsForIndexerCtx(ctx, indexer); err == nil {
+		if limit <= 0 {
+			limit = caps.Limits.Max
+		}
+
+		if caps.Limits.Max > 0 && limit > caps.Limits.Max {
+			limit = caps.Limits.Max
+		}
+	} else if ctx.Err() != nil {
+		return err
+	}
+
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	params := make(map[string]string, len(opts)+2)
+	for k, v := range opts {
+		params[k] = v
+	}
+
+	params["limit"] = strconv.Itoa(limit)
+
+	var (
+		total int
+		first string
+	)
+
+	for {
+		params["offset"] = strconv.Itoa(offset)
+
+		rss, err := c.GetTorrentsCtx(ctx, indexer, params)
+		if err != nil {
+			return err
+		}
+
+		items := rss.Channel.Item
+		if len(items) == 0 {
+			return nil
+		}
+
+		key := itemKey(items[0])
+		if key == first {
+			return nil
+		}
+
+		first = key
+
+		full := len(items) >= limit
+
+		if maxResults > 0 && total+len(items) > maxResults {
+			items = items[:maxResults-total]
+		}
+
+		total += len(items)
+
+		if !fn(items) || !full || (maxResults > 0 && total >= maxResults) {
+			return nil
+		}
+
+		offset += len(rss.Channel.Item)
+	}
+}
+
+// IterateChan returns the items of SearchPagesCtx on a channel, closed after
+// the last item. The error of the search, if any, is sent on the error
+// channel, buffered so it never blocks, after the items channel is closed.
+// Cancel ctx to stop early.
+func (c *Client) IterateChan(ctx context.Context, indexer string, opts map[string]string, maxResults int) (<-chan FeedItem, <-chan error) {
+	items := make(chan FeedItem)
+	errc := make(chan error, 1)
+
+	go func() {
+		err := c.SearchPagesCtx(ctx, indexer, opts, maxResults, func(page []FeedItem) bool {
+			for _, item := range page {
+				select {
+				case items <- item:
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			return true
+		})
+
+		close(items)
+
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		errc <- err
+		close(errc)
+	}()
+
+	return items, errc
+}
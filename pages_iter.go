@@ -0,0 +1,31 @@
+//go:build go1.23
+
+package jackett
+
+import (
+	"context"
+	"iter"
+)
+
+// Iterate returns the items of SearchPagesCtx as an iterator. The error of
+// the search, if any, is yielded last with a zero item.
+func (c *Client) Iterate(ctx context.Context, indexer string, opts map[string]string, maxResults int) iter.Seq2[FeedItem, error] {
+	return func(yield func(FeedItem, error) bool) {
+		stopped := false
+
+		err := c.SearchPagesCtx(ctx, indexer, opts, maxResults, func(page []FeedItem) bool {
+			for _, item := range page {
+				if !yield(item, nil) {
+					stopped = true
+					return false
+				}
+			}
+
+			return true
+		})
+
+		if err != nil && !stopped {
+			yield(FeedItem{}, err)
+		}
+	}
+}
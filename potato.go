@@ -0,0 +1,93 @@
+package jackett
+
+import (
+	"context"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// PotatoResults is the response of the TorrentPotato api of Jackett, the
+// format CouchPotato consumes.
+type PotatoResults struct {
+	Results      []PotatoResult `json:"results"`
+	TotalResults int            `json:"total_results"`
+}
+
+// PotatoResult is a search result of the TorrentPotato api.
+type PotatoResult struct {
+	ReleaseName string `json:"release_name"`
+	TorrentID   string `json:"torrent_id"`
+	DetailsURL  string `json:"details_url"`
+	DownloadURL string `json:"download_url"`
+	IMDBID      string `json:"imdb_id"`
+	Freeleech   bool   `json:"freeleech"`
+	Type        string `json:"type"`
+
+	// megabytes
+	Size int64 `json:"size"`
+
+	Leechers int `json:"leechers"`
+	Seeders  int `json:"seeders"`
+
+	// e.g. 2006-01-02T15:04:05, in UTC
+	PublishDate string `json:"publish_date"`
+}
+
+// SizeBytes returns the size of the release in bytes, rounded to megabytes.
+func (r PotatoResult) SizeBytes() int64 {
+	return r.Size << 20
+}
+
+// PublishTime returns the parsed publish date, the zero time when it can't
+// be parsed.
+func (r PotatoResult) PublishTime() time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, r.PublishDate); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// SearchPotatoCtx searches an indexer, or "all", through the TorrentPotato
+// api of Jackett. opts are the params of the api, e.g. search or imdbid, the
+// api key is sent as the passkey. The api is not available in DirectMode.
+func (c *Client) SearchPotatoCtx(ctx context.Context, indexer string, opts map[string]string) (results PotatoResults, err error) {
+	if c.cfg.DirectMode {
+		return results, errors.New("the torrentpotato api is not available in direct mode")
+	}
+
+	if err := c.requireAPIKey(); err != nil {
+		return results, err
+	}
+
+	ctx, sp := c.startSpan(ctx, "potato", indexer)
+	defer func() { sp.end(err) }()
+
+	if err := c.spendBudget(ctx, indexer); err != nil {
+		return results, err
+	}
+
+	if err := c.waitRateLimit(ctx, indexer); err != nil {
+		return results, err
+	}
+
+	params := make(map[string]string, len(opts)+1)
+	for k, v := range opts {
+		params[k] = v
+	}
+
+	params["passkey"] = c.cfg.APIKey
+
+	resp, err := c.getCtx(ctx, indexer+"/potato/api", params)
+	if err != nil {
+		return results, errors.Wrap(err, indexer+" potato error")
+	}
+
+	defer drainAndClose(resp.Body)
+
+	err = decodeBody(resp, &results)
+	return results, err
+}
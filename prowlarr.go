@@ -0,0 +1,302 @@
+package jackett
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Backends selected by Config.Mode, see also ModeNZBHydra2.
+const (
+	ModeJackett  = "jackett"
+	ModeProwlarr = "prowlarr"
+)
+
+// ProwlarrPathTemplate is the path of the torznab api of a Prowlarr indexer,
+// whose ids are numbers.
+var ProwlarrPathTemplate = "/{indexer}/api"
+
+// ProwlarrIndexer is an indexer as listed by the Prowlarr api.
+type ProwlarrIndexer struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Enable      bool   `json:"enable"`
+
+	// public, semiPrivate or private
+	Privacy string `json:"privacy"`
+
+	// torrent or usenet
+	Protocol string `json:"protocol"`
+
+	Capabilities ProwlarrCapabilities `json:"capabilities"`
+}
+
+type ProwlarrCapabilities struct {
+	LimitsMax     int                `json:"limitsMax"`
+	LimitsDefault int                `json:"limitsDefault"`
+	Categories    []ProwlarrCategory `json:"categories"`
+
+	// camel cased torznab params, e.g. imdbId
+	SearchParams      []string `json:"searchParams"`
+	TvSearchParams    []string `json:"tvSearchParams"`
+	MovieSearchParams []string `json:"movieSearchParams"`
+	MusicSearchParams []string `json:"musicSearchParams"`
+	BookSearchParams  []string `json:"bookSearchParams"`
+}
+
+type ProwlarrCategory struct {
+	ID            int                `json:"id"`
+	Name          string             `json:"name"`
+	SubCategories []ProwlarrCategory `json:"subCategories"`
+}
+
+// ProwlarrResult is a search result of the Prowlarr api.
+type ProwlarrResult struct {
+	GUID        string             `json:"guid"`
+	Title       string             `json:"title"`
+	IndexerID   int                `json:"indexerId"`
+	Indexer     string             `json:"indexer"`
+	Protocol    string             `json:"protocol"`
+	Size        int64              `json:"size"`
+	Files       int                `json:"files"`
+	Grabs       int                `json:"grabs"`
+	Seeders     int                `json:"seeders"`
+	Leechers    int                `json:"leechers"`
+	PublishDate time.Time          `json:"publishDate"`
+	DownloadURL string             `json:"downloadUrl"`
+	InfoURL     string             `json:"infoUrl"`
+	MagnetURL   string             `json:"magnetUrl"`
+	InfoHash    string             `json:"infoHash"`
+	Categories  []ProwlarrCategory `json:"categories"`
+
+	IMDB   int `json:"imdbId"`
+	TMDB   int `json:"tmdbId"`
+	TVDB   int `json:"tvdbId"`
+	TVMaze int `json:"tvMazeId"`
+}
+
+// FeedItem converts the result to the item of a torznab response, so it can
+// be used with the rest of the library.
+func (r ProwlarrResult) FeedItem() FeedItem {
+	item := FeedItem{
+		Title:    r.Title,
+		Guid:     r.GUID,
+		Link:     r.DownloadURL,
+		Comments: r.InfoURL,
+		Size:     strconv.FormatInt(r.Size, 10),
+		Jackettindexer: JackettIndexer{
+			Text: r.Indexer,
+			ID:   strconv.Itoa(r.IndexerID),
+		},
+		Enclosure: Enclosure{
+			URL:    r.DownloadURL,
+			Length: strconv.FormatInt(r.Size, 10),
+		},
+	}
+
+	if !r.PublishDate.IsZero() {
+		item.PubDate = r.PublishDate.Format(time.RFC1123Z)
+	}
+
+	switch r.Protocol {
+	case "torrent":
+		item.Enclosure.Type = "application/x-bittorrent"
+	case "usenet":
+		item.Enclosure.Type = NZBContentType
+	}
+
+	for _, cat := range r.Categories {
+		item.Category = append(item.Category, strconv.Itoa(cat.ID))
+	}
+
+	attr := func(name AttrName, value string) {
+		if value != "" && value != "0" {
+			item.Attr = append(item.Attr, ItemAttr{Name: string(name), Value: value})
+		}
+	}
+
+	if r.Protocol == "torrent" {
+		attr(AttrSeeders, strconv.Itoa(r.Seeders))
+		attr(AttrPeers, strconv.Itoa(r.Seeders+r.Leechers))
+	}
+
+	attr(AttrGrabs, strconv.Itoa(r.Grabs))
+	attr(AttrInfoHash, r.InfoHash)
+	attr(AttrMagnetURL, r.MagnetURL)
+	attr(AttrIMDBID, normalizeIMDBID(strconv.Itoa(r.IMDB)))
+	attr(AttrTMDBID, strconv.Itoa(r.TMDB))
+	attr(AttrTVDBID, strconv.Itoa(r.TVDB))
+	attr(AttrTVMazeID, strconv.Itoa(r.TVMaze))
+
+	return item
+}
+
+// GetProwlarrIndexersCtx lists the indexers of a Prowlarr server.
+func (c *Client) GetProwlarrIndexersCtx(ctx context.Context) ([]ProwlarrIndexer, error) {
+	var indexers []ProwlarrIndexer
+	if err := c.getProwlarrInto(ctx, "/api/v1/indexer", nil, &indexers); err != nil {
+		return nil, errors.Wrap(err, "could not get prowlarr indexers")
+	}
+
+	return indexers, nil
+}
+
+// SearchProwlarrCtx searches the indexers of a Prowlarr server through its
+// api, all of them when indexerIDs is empty, for the categories when given.
+func (c *Client) SearchProwlarrCtx(ctx context.Context, query string, indexerIDs []int, categories []int) ([]ProwlarrResult, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("type", "search")
+
+	for _, id := range indexerIDs {
+		params.Add("indexerIds", strconv.Itoa(id))
+	}
+
+	for _, cat := range categories {
+		params.Add("categories", strconv.Itoa(cat))
+	}
+
+	var results []ProwlarrResult
+	if err := c.getProwlarrInto(ctx, "/api/v1/search", params, &results); err != nil {
+		return nil, errors.Wrap(err, "could not search prowlarr")
+	}
+
+	return results, nil
+}
+
+func (c *Client) getProwlarrInto(ctx context.Context, endpoint string, params url.Values, v interface{}) error {
+	if err := c.requireAPIKey(); err != nil {
+		return err
+	}
+
+	reqUrl, _ := url.JoinPath(c.host(), endpoint)
+	if len(params) > 0 {
+		reqUrl += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqUrl, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not build request")
+	}
+
+	if c.cfg.BasicUser != "" && c.cfg.BasicPass != "" {
+		req.SetBasicAuth(c.cfg.BasicUser, c.cfg.BasicPass)
+	}
+
+	req.Header.Set("X-Api-Key", c.cfg.APIKey)
+
+	resp, err := c.retryDo(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "error making get request: %v", c.redact(reqUrl))
+	}
+
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return &StatusError{Status: resp.StatusCode, Body: truncateBody(b)}
+	}
+
+	return decodeBody(resp, v)
+}
+
+// indexerCategory is the type of the categories of Indexer caps.
+type indexerCategory = struct {
+	Text   string `xml:",chardata"`
+	ID     string `xml:"id,attr"`
+	Name   string `xml:"name,attr"`
+	Subcat []struct {
+		Text string `xml:",chardata"`
+		ID   string `xml:"id,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"subcat"`
+}
+
+// Indexer converts the indexer to the form t=indexers lists them in, so
+// Prowlarr servers work with MultiClient and the caps helpers.
+func (p ProwlarrIndexer) Indexer() Indexer {
+	ind := Indexer{
+		ID:          strconv.Itoa(p.ID),
+		Configured:  strconv.FormatBool(p.Enable),
+		Title:       p.Name,
+		Description: p.Description,
+		Language:    p.Language,
+		Type:        prowlarrPrivacy(p.Privacy),
+	}
+
+	caps := p.Capabilities
+	ind.Caps.Limits.Default = strconv.Itoa(caps.LimitsDefault)
+	ind.Caps.Limits.Max = strconv.Itoa(caps.LimitsMax)
+
+	s := &ind.Caps.Searching
+	s.Search.Available, s.Search.SupportedParams = prowlarrParams(caps.SearchParams)
+	s.TvSearch.Available, s.TvSearch.SupportedParams = prowlarrParams(caps.TvSearchParams)
+	s.MovieSearch.Available, s.MovieSearch.SupportedParams = prowlarrParams(caps.MovieSearchParams)
+	s.MusicSearch.Available, s.MusicSearch.SupportedParams = prowlarrParams(caps.MusicSearchParams)
+	s.BookSearch.Available, s.BookSearch.SupportedParams = prowlarrParams(caps.BookSearchParams)
+
+	for _, cat := range caps.Categories {
+		c := indexerCategory{ID: strconv.Itoa(cat.ID), Name: cat.Name}
+		for _, sub := range cat.SubCategories {
+			c.Subcat = append(c.Subcat, struct {
+				Text string `xml:",chardata"`
+				ID   string `xml:"id,attr"`
+				Name string `xml:"name,attr"`
+			}{ID: strconv.Itoa(sub.ID), Name: sub.Name})
+		}
+
+		ind.Caps.Categories.Category = append(ind.Caps.Categories.Category, c)
+	}
+
+	return ind
+}
+
+// prowlarrPrivacy returns the Jackett indexer type of a Prowlarr privacy.
+func prowlarrPrivacy(privacy string) string {
+	if privacy == "semiPrivate" {
+		return "semi-private"
+	}
+
+	return strings.ToLower(privacy)
+}
+
+// prowlarrParams returns the available attribute and the supported params
+// of a search mode with the given Prowlarr params.
+func prowlarrParams(params []string) (string, string) {
+	if len(params) == 0 {
+		return "no", ""
+	}
+
+	lower := make([]string, 0, len(params))
+	for _, p := range params {
+		lower = append(lower, strings.ToLower(p))
+	}
+
+	return "yes", strings.Join(lower, ",")
+}
+
+// getProwlarrIndexers lists the indexers of a Prowlarr server as
+// GetIndexersCtx does for Jackett. Disabled indexers are left out.
+func (c *Client) getProwlarrIndexers(ctx context.Context) (Indexers, error) {
+	indexers, err := c.GetProwlarrIndexersCtx(ctx)
+	if err != nil {
+		return Indexers{}, err
+	}
+
+	var ind Indexers
+	for _, p := range indexers {
+		if p.Enable {
+			ind.Indexer = append(ind.Indexer, p.Indexer())
+		}
+	}
+
+	return ind, nil
+}
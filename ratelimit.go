@@ -0,0 +1,312 @@
+package jackett
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var ErrRateLimited = errors.Sentinel("rate limited")
+
+// RateLimitInfo is parsed from the rate limit headers some trackers send,
+// so callers can throttle before they get banned.
+type RateLimitInfo struct {
+	// path of the request the headers were received on
+	Endpoint string
+
+	// -1 when not sent
+	Limit     int
+	Remaining int
+
+	// zero when not sent
+	Reset time.Time
+}
+
+type rateLimitState struct {
+	mu   sync.Mutex
+	info RateLimitInfo
+	ok   bool
+}
+
+// parseRateLimit reads the X-RateLimit-* headers, or their unprefixed
+// RateLimit-* variants. Reset may be a unix timestamp or seconds from now.
+func parseRateLimit(h http.Header, now time.Time) (RateLimitInfo, bool) {
+	header := func(name string) string {
+		if v := h.Get("X-" + name); v != "" {
+			return v
+		}
+
+		return h.Get(name)
+	}
+
+	info := RateLimitInfo{Limit: -1, Remaining: -1}
+	found := false
+
+	if n, err := strconv.Atoi(header("RateLimit-Limit")); err == nil {
+		info.Limit = n
+		found = true
+	}
+
+	if n, err := strconv.Atoi(header("RateLimit-Remaining")); err == nil {
+		info.Remaining = n
+		found = true
+	}
+
+	if n, err := strconv.ParseInt(header("RateLimit-Reset"), 10, 64); err == nil {
+		// anything this large is a timestamp rather than a delay
+		if n > 1000000000 {
+			info.Reset = time.Unix(n, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+
+		found = true
+	}
+
+	return info, found
+}
+
+func (c *Client) recordRateLimit(resp *http.Response) {
+	info, ok := parseRateLimit(resp.Header, c.clock.Now())
+	if !ok {
+		return
+	}
+
+	info.Endpoint = resp.Request.URL.Path
+
+	c.rateLimit.mu.Lock()
+	c.rateLimit.info = info
+	c.rateLimit.ok = true
+	c.rateLimit.mu.Unlock()
+
+	if c.cfg.OnRateLimit != nil {
+		c.cfg.OnRateLimit(info)
+	}
+}
+
+// RateLimit returns the most recent rate limit headers received, if any.
+func (c *Client) RateLimit() (RateLimitInfo, bool) {
+	c.rateLimit.mu.Lock()
+	defer c.rateLimit.mu.Unlock()
+
+	return c.rateLimit.info, c.rateLimit.ok
+}
+
+// RateLimitError is returned when the server still answered 429 Too Many
+// Requests when the retries ran out, or asked for a wait longer than the
+// RetryPolicy MaxDelay. It matches ErrRateLimited.
+type RateLimitError struct {
+	Status int
+
+	// when the server asked to be retried, zero when it didn't say
+	RetryAt time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAt.IsZero() {
+		return "rate limited: status " + strconv.Itoa(e.Status)
+	}
+
+	return "rate limited: status " + strconv.Itoa(e.Status) + ", retry at " + e.RetryAt.Format(time.RFC3339)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// parseRetryAfter reads the Retry-After header, which holds either seconds
+// or an http date. It returns the zero time when there is none.
+func parseRetryAfter(h http.Header, now time.Time) time.Time {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return time.Time{}
+	}
+
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return now.Add(time.Duration(n) * time.Second)
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return t
+	}
+
+	return time.Time{}
+}
+
+// RateLimit is a token bucket allowing Requests every Per seconds, e.g.
+// {Requests: 10, Per: 60} for 10 requests a minute.
+type RateLimit struct {
+	Requests int `json:"requests"`
+	Per      int `json:"per"`
+
+	// requests allowed at once after a quiet period, defaults to 1
+	Burst int `json:"burst,omitempty"`
+
+	// fail with ErrRateLimited instead of waiting for the next token
+	Reject bool `json:"reject,omitempty"`
+}
+
+func (rl RateLimit) validate() error {
+	if rl.Requests <= 0 || rl.Per <= 0 {
+		return errors.New("invalid rate limit: %v requests per %vs", rl.Requests, rl.Per)
+	}
+
+	return nil
+}
+
+// RateLimiter delays or rejects requests before they are sent.
+type RateLimiter interface {
+	// Wait blocks until a request to the indexer may be sent, or returns an
+	// error when it may not, e.g. because ctx is done.
+	Wait(ctx context.Context, indexer string) error
+}
+
+// Limiter is a RateLimiter of token buckets, one for all requests and one per
+// indexer. A request takes a token from both.
+type Limiter struct {
+	clock Clock
+
+	mu       sync.Mutex
+	all      *bucket
+	indexers map[string]*bucket
+}
+
+// NewRateLimiter returns a limiter for all requests, when all is not nil, and
+// for the requests to the indexers in indexers. One limiter can be shared by
+// the clients of a host through Config.RateLimiter.
+func NewRateLimiter(all *RateLimit, indexers map[string]RateLimit) *Limiter {
+	return newRateLimiter(all, indexers, systemClock{})
+}
+
+func newRateLimiter(all *RateLimit, indexers map[string]RateLimit, clock Clock) *Limiter {
+	l := &Limiter{clock: clock, indexers: make(map[string]*bucket, len(indexers))}
+
+	if all != nil {
+		l.all = newBucket(*all)
+	}
+
+	for indexer, rl := range indexers {
+		l.indexers[indexer] = newBucket(rl)
+	}
+
+	return l
+}
+
+func (l *Limiter) Wait(ctx context.Context, indexer string) error {
+	l.mu.Lock()
+
+	now := l.clock.Now()
+
+	buckets := make([]*bucket, 0, 2)
+	if l.all != nil {
+		buckets = append(buckets, l.all)
+	}
+
+	if b, ok := l.indexers[indexer]; ok {
+		buckets = append(buckets, b)
+	}
+
+	for _, b := range buckets {
+		b.refill(now)
+
+		if b.limit.Reject && b.tokens < 1 {
+			l.mu.Unlock()
+			return errors.Wrap(ErrRateLimited, "%v: next request in %v", indexer, b.delay(1).Round(time.Millisecond))
+		}
+	}
+
+	// take the tokens now, waiting for them to refill if needed
+	var wait time.Duration
+	for _, b := range buckets {
+		b.tokens--
+		if d := b.delay(0); d > wait {
+			wait = d
+		}
+	}
+
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-l.clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, b := range buckets {
+			b.refill(l.clock.Now())
+			b.tokens++
+		}
+		l.mu.Unlock()
+
+		return errors.Wrap(ctx.Err(), "waiting for rate limit")
+	}
+}
+
+type bucket struct {
+	limit RateLimit
+
+	// per second
+	rate  float64
+	burst float64
+
+	// negative while requests wait for tokens
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rl RateLimit) *bucket {
+	burst := rl.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	b := &bucket{
+		limit:  rl,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+
+	if rl.Requests > 0 && rl.Per > 0 {
+		b.rate = float64(rl.Requests) / float64(rl.Per)
+	}
+
+	return b
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+	}
+
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	b.last = now
+}
+
+// delay returns how long until the bucket holds n tokens.
+func (b *bucket) delay(n float64) time.Duration {
+	if b.tokens >= n || b.rate == 0 {
+		return 0
+	}
+
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// waitRateLimit waits for the rate limiter of the client, if any.
+func (c *Client) waitRateLimit(ctx context.Context, indexer string) error {
+	l := c.rateLimiter()
+	if l == nil {
+		return nil
+	}
+
+	return l.Wait(ctx, indexer)
+}
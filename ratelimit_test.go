@@ -0,0 +1,106 @@
+package jackett
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    RateLimitInfo
+		ok      bool
+	}{
+		{
+			name: "none",
+			want: RateLimitInfo{Limit: -1, Remaining: -1},
+		},
+		{
+			name:    "prefixed",
+			headers: map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "42", "X-RateLimit-Reset": "30"},
+			want:    RateLimitInfo{Limit: 100, Remaining: 42, Reset: now.Add(30 * time.Second)},
+			ok:      true,
+		},
+		{
+			name:    "unprefixed",
+			headers: map[string]string{"RateLimit-Limit": "10", "RateLimit-Remaining": "0"},
+			want:    RateLimitInfo{Limit: 10, Remaining: 0},
+			ok:      true,
+		},
+		{
+			name:    "prefixed preferred",
+			headers: map[string]string{"X-RateLimit-Remaining": "5", "RateLimit-Remaining": "9"},
+			want:    RateLimitInfo{Limit: -1, Remaining: 5},
+			ok:      true,
+		},
+		{
+			name:    "reset timestamp",
+			headers: map[string]string{"X-RateLimit-Reset": "1717243260"},
+			want:    RateLimitInfo{Limit: -1, Remaining: -1, Reset: time.Unix(1717243260, 0)},
+			ok:      true,
+		},
+		{
+			name:    "invalid values",
+			headers: map[string]string{"X-RateLimit-Limit": "many", "X-RateLimit-Reset": "soon"},
+			want:    RateLimitInfo{Limit: -1, Remaining: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := make(http.Header)
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			info, ok := parseRateLimit(h, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, info)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{name: "none"},
+		{name: "seconds", value: "120", want: now.Add(2 * time.Minute)},
+		{name: "zero seconds", value: "0", want: now},
+		{name: "http date", value: "Sat, 01 Jun 2024 12:05:00 GMT", want: now.Add(5 * time.Minute)},
+		{name: "negative", value: "-5"},
+		{name: "garbage", value: "later"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := make(http.Header)
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+
+			assert.True(t, tt.want.Equal(parseRetryAfter(h, now)))
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	err := error(&RateLimitError{Status: 429})
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, "rate limited: status 429", err.Error())
+
+	err = &RateLimitError{Status: 429, RetryAt: time.Date(2024, 6, 1, 12, 5, 0, 0, time.UTC)}
+	assert.Equal(t, "rate limited: status 429, retry at 2024-06-01T12:05:00Z", err.Error())
+}
@@ -0,0 +1,116 @@
+package jackett
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Registry manages named clients, e.g. one per user or tenant, sharing a
+// single transport so connections are pooled across all of them.
+type Registry struct {
+	mu        sync.RWMutex
+	clients   map[string]*Client
+	transport http.RoundTripper
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		clients:   make(map[string]*Client),
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+}
+
+func (r *Registry) newClient(cfg Config) *Client {
+	c := NewClient(cfg)
+	if cfg.sharesTransport() {
+		c.http.Transport = r.transport
+	}
+
+	return c
+}
+
+func (r *Registry) Add(name string, cfg Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid config for client %v", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[name]; ok {
+		return nil, errors.New("client already registered: %v", name)
+	}
+
+	c := r.newClient(cfg)
+	r.clients[name] = c
+
+	return c, nil
+}
+
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[name]; !ok {
+		return false
+	}
+
+	delete(r.clients, name)
+	return true
+}
+
+func (r *Registry) Get(name string) (*Client, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.clients[name]
+	return c, ok
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Reload validates the given configuration and atomically swaps it in.
+// Clients whose configuration is unchanged are kept, and searches already
+// running on replaced clients complete undisturbed. Configs with callbacks
+// never compare equal, so those clients are always rebuilt.
+func (r *Registry) Reload(cfgs map[string]Config) error {
+	for name, cfg := range cfgs {
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "invalid config for client %v", name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make(map[string]*Client, len(cfgs))
+	for name, cfg := range cfgs {
+		// compare against the host as normalized by NewClient
+		cfg.Host, _ = NormalizeHost(cfg.Host)
+
+		if c, ok := r.clients[name]; ok && reflect.DeepEqual(c.cfg, cfg) {
+			clients[name] = c
+			continue
+		}
+
+		clients[name] = r.newClient(cfg)
+	}
+
+	r.clients = clients
+	return nil
+}
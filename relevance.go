@@ -0,0 +1,94 @@
+package jackett
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	yearRegexp    = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+	episodeRegexp = regexp.MustCompile(`(?i)\bs(\d{1,3})(?:e(\d{1,4}))?\b`)
+)
+
+// Relevance scores how well the title of item fits the query and ids, from 0
+// for obviously unrelated results to 1. Matching ids score 1 and
+// conflicting ids 0, otherwise the score is the share of query words found
+// in the title, zeroed on a season or episode mismatch and halved on a year
+// mismatch.
+func Relevance(query string, ids ExternalIDs, item FeedItem) float64 {
+	matched, conflict := compareIDs(item.ExternalIDs(), ids)
+	if conflict {
+		return 0
+	}
+
+	if matched {
+		return 1
+	}
+
+	queryWords := relevanceWords(query)
+	if len(queryWords) == 0 {
+		return 1
+	}
+
+	titleWords := make(map[string]bool)
+	for _, word := range relevanceWords(item.Title) {
+		titleWords[word] = true
+	}
+
+	found := 0
+	for _, word := range queryWords {
+		if titleWords[word] {
+			found++
+		}
+	}
+
+	score := float64(found) / float64(len(queryWords))
+
+	if q := episodeRegexp.FindStringSubmatch(query); q != nil {
+		t := episodeRegexp.FindStringSubmatch(item.Title)
+		if t == nil || trimZeros(t[1]) != trimZeros(q[1]) || (q[2] != "" && t[2] != "" && trimZeros(t[2]) != trimZeros(q[2])) {
+			return 0
+		}
+	}
+
+	if qy := yearRegexp.FindString(query); qy != "" {
+		if ty := yearRegexp.FindString(item.Title); ty != "" && ty != qy {
+			score /= 2
+		}
+	}
+
+	return score
+}
+
+// FilterRelevant drops the items scoring below min.
+func FilterRelevant(query string, ids ExternalIDs, items []FeedItem, min float64) []FeedItem {
+	var relevant []FeedItem
+	for _, item := range items {
+		if Relevance(query, ids, item) >= min {
+			relevant = append(relevant, item)
+		}
+	}
+
+	return relevant
+}
+
+// relevanceWords splits s into lower case words, leaving out the season,
+// episode and year tokens which are compared separately.
+func relevanceWords(s string) []string {
+	s = episodeRegexp.ReplaceAllString(s, " ")
+	s = yearRegexp.ReplaceAllString(s, " ")
+
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func trimZeros(s string) string {
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+
+	return s
+}
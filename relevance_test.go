@@ -0,0 +1,146 @@
+package jackett_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// idItem returns an item titled title with the attrs, name value pairs.
+func idItem(title string, attrs ...string) jackett.FeedItem {
+	item := jackett.FeedItem{Title: title}
+	for n := 0; n+1 < len(attrs); n += 2 {
+		item.Attr = append(item.Attr, jackett.ItemAttr{Name: attrs[n], Value: attrs[n+1]})
+	}
+
+	return item
+}
+
+func TestRelevance(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		ids   jackett.ExternalIDs
+		item  jackett.FeedItem
+		want  float64
+	}{
+		{
+			name:  "all words",
+			query: "the matrix",
+			item:  idItem("The.Matrix.1999.1080p.BluRay"),
+			want:  1,
+		},
+		{
+			name:  "some words",
+			query: "the matrix reloaded",
+			item:  idItem("The.Matrix.1999.1080p"),
+			want:  2.0 / 3,
+		},
+		{
+			name:  "no words",
+			query: "ubuntu",
+			item:  idItem("Debian 12 netinst"),
+			want:  0,
+		},
+		{
+			name:  "empty query",
+			query: "",
+			item:  idItem("Anything"),
+			want:  1,
+		},
+		{
+			name:  "year mismatch",
+			query: "the matrix 1999",
+			item:  idItem("The.Matrix.2021.1080p"),
+			want:  0.5,
+		},
+		{
+			name:  "year missing from title",
+			query: "the matrix 1999",
+			item:  idItem("The.Matrix.1080p"),
+			want:  1,
+		},
+		{
+			name:  "episode match with leading zeros",
+			query: "show s1e2",
+			item:  idItem("Show.S01E02.720p"),
+			want:  1,
+		},
+		{
+			name:  "episode mismatch",
+			query: "show s01e02",
+			item:  idItem("Show.S01E03.720p"),
+			want:  0,
+		},
+		{
+			name:  "season mismatch",
+			query: "show s02",
+			item:  idItem("Show.S01E03.720p"),
+			want:  0,
+		},
+		{
+			name:  "season pack for an episode",
+			query: "show s01e02",
+			item:  idItem("Show.S01.1080p"),
+			want:  1,
+		},
+		{
+			name:  "episode query on a title without one",
+			query: "show s01e02",
+			item:  idItem("Show.2019.1080p"),
+			want:  0,
+		},
+		{
+			name:  "matching id",
+			query: "something else",
+			ids:   jackett.ExternalIDs{IMDB: "133093"},
+			item:  idItem("Unrelated title", "imdb", "0133093"),
+			want:  1,
+		},
+		{
+			name:  "conflicting id",
+			query: "the matrix",
+			ids:   jackett.ExternalIDs{TVDB: 1},
+			item:  idItem("The.Matrix.1999", "tvdbid", "2"),
+			want:  0,
+		},
+		{
+			name:  "conflict beats a match",
+			query: "the matrix",
+			ids:   jackett.ExternalIDs{IMDB: "tt0133093", TVDB: 1},
+			item:  idItem("The.Matrix.1999", "imdb", "tt0133093", "tvdbid", "2"),
+			want:  0,
+		},
+		{
+			name:  "id the item doesn't have",
+			query: "the matrix",
+			ids:   jackett.ExternalIDs{TMDB: 603},
+			item:  idItem("The.Matrix.1999"),
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, jackett.Relevance(tt.query, tt.ids, tt.item), 0.001)
+		})
+	}
+}
+
+func TestFilterRelevant(t *testing.T) {
+	items := []jackett.FeedItem{
+		idItem("Show.S01E02.1080p"),
+		idItem("Show.S01E03.1080p"),
+		idItem("Other.Show.S01E02.720p"),
+		idItem("Unrelated.S01E02"),
+	}
+
+	var titles []string
+	for _, item := range jackett.FilterRelevant("other show s01e02", jackett.ExternalIDs{}, items, 0.5) {
+		titles = append(titles, item.Title)
+	}
+
+	assert.Equal(t, []string{"Show.S01E02.1080p", "Other.Show.S01E02.720p"}, titles)
+}
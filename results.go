@@ -0,0 +1,157 @@
+package jackett
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// JSONResults is the response of the JSON results api of Jackett.
+type JSONResults struct {
+	Results []JSONResult `json:"Results"`
+
+	// the indexers searched and how they did
+	Indexers []JSONIndexerStatus `json:"Indexers"`
+}
+
+// JSONResult is a search result of the JSON results api, which unlike the
+// torznab api reports numbers as numbers. Ids Jackett doesn't know are 0.
+type JSONResult struct {
+	Tracker      string `json:"Tracker"`
+	TrackerID    string `json:"TrackerId"`
+	TrackerType  string `json:"TrackerType"`
+	CategoryDesc string `json:"CategoryDesc"`
+
+	Title       string `json:"Title"`
+	GUID        string `json:"Guid"`
+	Link        string `json:"Link"`
+	Details     string `json:"Details"`
+	MagnetURI   string `json:"MagnetUri"`
+	InfoHash    string `json:"InfoHash"`
+	Poster      string `json:"Poster"`
+	Description string `json:"Description"`
+
+	// see PublishTime
+	PublishDate string `json:"PublishDate"`
+	FirstSeen   string `json:"FirstSeen"`
+
+	Category []int    `json:"Category"`
+	Size     int64    `json:"Size"`
+	Files    int      `json:"Files"`
+	Grabs    int      `json:"Grabs"`
+	Seeders  int      `json:"Seeders"`
+	Peers    int      `json:"Peers"`
+	Year     int      `json:"Year"`
+	Genres   []string `json:"Genres"`
+
+	IMDB   int `json:"Imdb"`
+	TMDB   int `json:"TMDb"`
+	TVDB   int `json:"TVDBId"`
+	TVMaze int `json:"TVMazeId"`
+	TVRage int `json:"RageID"`
+	Trakt  int `json:"TraktId"`
+
+	// seconds
+	MinimumSeedTime int64   `json:"MinimumSeedTime"`
+	MinimumRatio    float64 `json:"MinimumRatio"`
+
+	DownloadVolumeFactor float64 `json:"DownloadVolumeFactor"`
+	UploadVolumeFactor   float64 `json:"UploadVolumeFactor"`
+
+	// seeders times size in GB, how much uploading the release might earn
+	Gain float64 `json:"Gain"`
+}
+
+// PublishTime parses PublishDate, which Jackett sends with or without a
+// zone. It returns the zero time when the date is missing or invalid.
+func (r JSONResult) PublishTime() time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, r.PublishDate); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (r JSONResult) ExternalIDs() ExternalIDs {
+	return ExternalIDs{
+		IMDB:   normalizeIMDBID(strconv.Itoa(r.IMDB)),
+		TMDB:   r.TMDB,
+		TVDB:   r.TVDB,
+		TVMaze: r.TVMaze,
+		TVRage: r.TVRage,
+		Trakt:  r.Trakt,
+	}
+}
+
+// Freeleech reports whether downloading the release doesn't count against
+// the ratio.
+func (r JSONResult) Freeleech() bool {
+	return r.DownloadVolumeFactor == 0
+}
+
+type JSONIndexerStatus struct {
+	ID      string `json:"ID"`
+	Name    string `json:"Name"`
+	Status  int    `json:"Status"`
+	Results int    `json:"Results"`
+	Error   string `json:"Error"`
+
+	// milliseconds
+	ElapsedTime int64 `json:"ElapsedTime"`
+}
+
+// GetResultsJSONCtx searches an indexer, or "all", through the JSON results
+// api of Jackett instead of the torznab api, for the categories when given.
+// The api is not available in DirectMode.
+func (c *Client) GetResultsJSONCtx(ctx context.Context, indexer, query string, categories ...int) (results JSONResults, err error) {
+	if c.cfg.DirectMode {
+		return results, errors.New("the json results api is not available in direct mode")
+	}
+
+	if err := c.requireAPIKey(); err != nil {
+		return results, err
+	}
+
+	ctx, sp := c.startSpan(ctx, "results", indexer)
+	defer func() { sp.end(err) }()
+
+	if err := c.spendBudget(ctx, indexer); err != nil {
+		return results, err
+	}
+
+	if err := c.waitRateLimit(ctx, indexer); err != nil {
+		return results, err
+	}
+
+	params := map[string]string{"Query": query}
+	if c.cfg.APIKey != "" && !c.cfg.APIKeyHeader {
+		params["apikey"] = c.cfg.APIKey
+	}
+
+	u, err := url.Parse(c.buildUrl(indexer+"/results", params))
+	if err != nil {
+		return results, errors.Wrap(err, "could not build request")
+	}
+
+	q := u.Query()
+	for _, cat := range categories {
+		q.Add("Category[]", strconv.Itoa(cat))
+	}
+
+	u.RawQuery = q.Encode()
+
+	resp, err := c.getRawCtx(ctx, u.String())
+	if err != nil {
+		return results, errors.Wrap(err, indexer+" results error")
+	}
+
+	defer drainAndClose(resp.Body)
+
+	err = decodeBody(resp, &results)
+	return results, err
+}
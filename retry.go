@@ -0,0 +1,90 @@
+package jackett
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// DefaultRetryStatuses are the response statuses retried by default, the
+// ones proxies and Jackett answer with while trackers or Jackett itself are
+// briefly unavailable.
+var DefaultRetryStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy decides how often and when failed requests are retried. Network
+// errors are always retryable, responses only when their status is listed in
+// Statuses or is 429 Too Many Requests. A Retry-After header replaces the
+// backoff delay. Zero fields take the defaults.
+type RetryPolicy struct {
+	// attempts per request including the first, defaults to 5
+	Attempts int
+
+	// delay before the first retry, doubled for every retry after it,
+	// defaults to 100ms
+	BaseDelay time.Duration
+
+	// bound of the doubled delay, defaults to 30s
+	MaxDelay time.Duration
+
+	// up to Jitter is added to every delay at random, defaults to
+	// BaseDelay, negative disables it
+	Jitter time.Duration
+
+	// retryable response statuses, defaults to DefaultRetryStatuses
+	Statuses []int
+}
+
+const retryAttempts = 5
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = retryAttempts
+	}
+
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 100 * time.Millisecond
+	}
+
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+
+	if p.Jitter == 0 {
+		p.Jitter = p.BaseDelay
+	}
+
+	if p.Statuses == nil {
+		p.Statuses = DefaultRetryStatuses
+	}
+
+	return p
+}
+
+func (p RetryPolicy) retryable(status int) bool {
+	for _, s := range p.Statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay returns the delay before retry n+1, backing off exponentially.
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.MaxDelay
+	if n < 32 && p.BaseDelay<<n > 0 && p.BaseDelay<<n < p.MaxDelay {
+		d = p.BaseDelay << n
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+
+	return d
+}
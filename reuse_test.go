@@ -0,0 +1,203 @@
+package jackett_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// reuseServer answers by the q param: "retry" fails twice with 503 before
+// succeeding, "unavailable" always fails with 503, "missing" is a 404,
+// "garbage" and "truncated" don't decode, "error" is a torznab error and
+// anything else succeeds. Every failure comes with a body the client has to
+// drain to reuse the connection.
+type reuseServer struct {
+	*httptest.Server
+
+	conns int64
+
+	mu      sync.Mutex
+	retries map[string]int
+}
+
+func newReuseServer(t *testing.T) *reuseServer {
+	s := &reuseServer{retries: make(map[string]int)}
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&s.conns, 1)
+		}
+	}
+
+	s.Start()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *reuseServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	filler := strings.Repeat("x", 16<<10)
+
+	q := r.URL.Query().Get("q")
+	switch {
+	case strings.HasPrefix(q, "retry"):
+		s.mu.Lock()
+		s.retries[q]++
+		n := s.retries[q]
+		s.mu.Unlock()
+
+		if n <= 2 {
+			http.Error(w, filler, http.StatusServiceUnavailable)
+			return
+		}
+	case q == "unavailable":
+		http.Error(w, filler, http.StatusServiceUnavailable)
+		return
+	case q == "missing":
+		http.Error(w, filler, http.StatusNotFound)
+		return
+	case q == "garbage":
+		w.Write([]byte(filler))
+		return
+	case q == "truncated":
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>%s`, filler)
+		return
+	case q == "error":
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><error code="900" description="%s" />`, filler)
+		return
+	}
+
+	fmt.Fprintf(w, testFeed, q, q, s.URL, q, s.URL, q)
+}
+
+func (s *reuseServer) connections() int64 {
+	return atomic.LoadInt64(&s.conns)
+}
+
+// reuseCounter counts the connections obtained for requests and how many of
+// them were reused.
+type reuseCounter struct {
+	got, reused int64
+}
+
+func (rc *reuseCounter) context() context.Context {
+	return httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			atomic.AddInt64(&rc.got, 1)
+			if info.Reused {
+				atomic.AddInt64(&rc.reused, 1)
+			}
+		},
+	})
+}
+
+// newReuseClient returns a client retrying quickly and keeping up to idle
+// connections to the host open, the default transport keeps only 2. It opens
+// no more than idle either, the transport would otherwise dial spare
+// connections for requests it then serves with one returned meanwhile.
+func newReuseClient(host string, idle int) *jackett.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = idle
+	transport.MaxConnsPerHost = idle
+
+	return jackett.NewClient(jackett.Config{
+		Host:      host,
+		APIKey:    "apikey",
+		Transport: transport,
+		Retry: &jackett.RetryPolicy{
+			Attempts:  3,
+			BaseDelay: time.Millisecond,
+			Jitter:    -1,
+		},
+	})
+}
+
+// TestConnectionReuse sends sequential requests, failing and retried ones
+// among them, and expects all of them to share a single connection.
+func TestConnectionReuse(t *testing.T) {
+	srv := newReuseServer(t)
+	client := newReuseClient(srv.URL, 1)
+
+	var rc reuseCounter
+	ctx := rc.context()
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		query := fmt.Sprintf("release%d", i)
+		rss, err := client.GetTorrentsCtx(ctx, "all", map[string]string{"t": "search", "q": query})
+		require.NoError(t, err)
+		require.Len(t, rss.Channel.Item, 1)
+
+		b, err := client.GetEnclosureCtx(ctx, rss.Channel.Item[0].Enclosure.URL)
+		require.NoError(t, err)
+		assert.NotEmpty(t, b)
+
+		// succeeds on the third attempt
+		_, err = client.GetTorrentsCtx(ctx, "all", map[string]string{"t": "search", "q": fmt.Sprintf("retry%d", i)})
+		require.NoError(t, err)
+
+		for _, q := range []string{"unavailable", "missing", "garbage", "truncated", "error"} {
+			_, err := client.GetTorrentsCtx(ctx, "all", map[string]string{"t": "search", "q": q})
+			assert.Error(t, err, q)
+		}
+	}
+
+	// search, enclosure, 3 attempts of the retried search, 3 attempts of
+	// the unavailable search and one each of the other failing searches
+	assert.Equal(t, int64(n*(1+1+3+3+4)), atomic.LoadInt64(&rc.got))
+	assert.Equal(t, atomic.LoadInt64(&rc.got)-1, atomic.LoadInt64(&rc.reused))
+	assert.Equal(t, int64(1), srv.connections())
+}
+
+// TestConnectionReuseUnderLoad runs concurrent searches and expects the
+// number of connections to stay bounded by the concurrency rather than grow
+// with the requests.
+func TestConnectionReuseUnderLoad(t *testing.T) {
+	const (
+		workers    = 4
+		iterations = 50
+	)
+
+	srv := newReuseServer(t)
+	client := newReuseClient(srv.URL, workers)
+
+	var rc reuseCounter
+	ctx := rc.context()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				q := fmt.Sprintf("release%d-%d", w, i)
+				if i%5 == 0 {
+					q = "missing"
+				}
+
+				_, _ = client.GetTorrentsCtx(ctx, "all", map[string]string{"t": "search", "q": q})
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(workers*iterations), atomic.LoadInt64(&rc.got))
+	assert.LessOrEqual(t, srv.connections(), int64(workers))
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&rc.reused), int64(workers*iterations-workers))
+}
@@ -0,0 +1,61 @@
+package jackett
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// EnclosureRewriter rewrites a download url before it is requested or handed
+// out, e.g. to swap the internal host of Jackett for its public one, or to
+// add a signed token for a download proxy. Magnet links are not rewritten.
+type EnclosureRewriter func(rawURL string) (string, error)
+
+// HostRewriter returns an EnclosureRewriter moving the urls on the from host
+// to the to host, keeping their path and query, e.g.
+// HostRewriter("http://jackett:9117", "https://jackett.example.com").
+// Other urls are returned as is.
+func HostRewriter(from, to string) EnclosureRewriter {
+	src, srcErr := url.Parse(from)
+	dst, dstErr := url.Parse(to)
+
+	return func(rawURL string) (string, error) {
+		if srcErr != nil {
+			return "", errors.Wrap(srcErr, "invalid host: %v", from)
+		}
+
+		if dstErr != nil {
+			return "", errors.Wrap(dstErr, "invalid host: %v", to)
+		}
+
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid url")
+		}
+
+		if !strings.EqualFold(u.Host, src.Host) {
+			return rawURL, nil
+		}
+
+		u.Scheme, u.Host = dst.Scheme, dst.Host
+		u.Path = strings.TrimRight(dst.Path, "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(u.Path, strings.TrimRight(src.Path, "/")), "/")
+
+		return u.String(), nil
+	}
+}
+
+// EnclosureURL returns the download url rewritten by Config.RewriteEnclosure,
+// or as is when none is set.
+func (c *Client) EnclosureURL(rawURL string) (string, error) {
+	if c.cfg.RewriteEnclosure == nil || rawURL == "" || strings.HasPrefix(rawURL, "magnet:") {
+		return rawURL, nil
+	}
+
+	rewritten, err := c.cfg.RewriteEnclosure(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "could not rewrite %v", c.redact(rawURL))
+	}
+
+	return rewritten, nil
+}
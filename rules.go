@@ -0,0 +1,434 @@
+package jackett
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+type RuleAction string
+
+const (
+	RuleGrab    RuleAction = "grab"
+	RuleNotify  RuleAction = "notify"
+	RuleWebhook RuleAction = "webhook"
+)
+
+// RuleFilter selects the items a rule applies to. Empty fields match
+// everything.
+type RuleFilter struct {
+	// names of the feeds the rule applies to
+	Feeds []string `json:"feeds,omitempty"`
+
+	// ids of the indexers the items have to come from
+	Indexers []string `json:"indexers,omitempty"`
+
+	// regular expressions the title has to match one of, and none of
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
+	// the item has to be in one of the categories, or their group
+	Categories []int `json:"categories,omitempty"`
+
+	MinSeeders int   `json:"minSeeders,omitempty"`
+	MinSize    int64 `json:"minSize,omitempty"`
+	MaxSize    int64 `json:"maxSize,omitempty"`
+}
+
+// Rule performs an action on the items matching its filter.
+type Rule struct {
+	Name string `json:"name"`
+
+	// rules are evaluated from the highest priority down
+	Priority int `json:"priority,omitempty"`
+
+	Filter RuleFilter `json:"filter"`
+	Action RuleAction `json:"action"`
+
+	// download client and category the action targets, e.g. for grabs
+	Target   string `json:"target,omitempty"`
+	Category string `json:"category,omitempty"`
+
+	// url RuleWebhook posts matches to
+	WebhookURL string `json:"webhookUrl,omitempty"`
+
+	// limit how often the action runs, a rule on cooldown matches without
+	// running its action
+	Cooldowns []Cooldown `json:"cooldowns,omitempty"`
+
+	// keep evaluating lower priority rules after a match
+	Continue bool `json:"continue,omitempty"`
+}
+
+// RuleHandler performs the action of a rule for a matching item.
+type RuleHandler func(ctx context.Context, rule Rule, feed string, item FeedItem) error
+
+type RuleStats struct {
+	Matched uint64
+	Failed  uint64
+
+	// matches skipped because the rule was on cooldown
+	Suppressed uint64
+
+	LastMatch time.Time
+}
+
+// RuleEngine evaluates rules against new items, usually on behalf of a
+// Watcher through WatcherConfig.Rules.
+type RuleEngine struct {
+	rules    []compiledRule
+	handlers map[RuleAction]RuleHandler
+	clock    Clock
+
+	// serializes checking and recording the cooldowns, actions running
+	// are reserved, counting towards them until they are recorded
+	cooldownMu sync.Mutex
+	cooldowns  CooldownStore
+	reserved   map[string]int
+
+	mu    sync.Mutex
+	stats map[string]RuleStats
+}
+
+type compiledRule struct {
+	Rule
+	feeds    map[string]bool
+	indexers map[string]bool
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+}
+
+// NewRuleEngine compiles the rules. Handlers perform the actions, webhooks
+// are posted as JSON by default, every other action needs a handler.
+func NewRuleEngine(rules []Rule, handlers map[RuleAction]RuleHandler) (*RuleEngine, error) {
+	e := &RuleEngine{
+		handlers: map[RuleAction]RuleHandler{RuleWebhook: postWebhook},
+		clock:    systemClock{},
+		stats:    make(map[string]RuleStats),
+	}
+
+	for action, h := range handlers {
+		e.handlers[action] = h
+	}
+
+	var retain time.Duration
+
+	names := make(map[string]bool)
+	for _, r := range rules {
+		if names[r.Name] {
+			return nil, errors.New("duplicate rule: %v", r.Name)
+		}
+
+		names[r.Name] = true
+
+		if _, ok := e.handlers[r.Action]; !ok {
+			return nil, errors.New("rule %v: no handler for action %q", r.Name, r.Action)
+		}
+
+		if r.Action == RuleWebhook && r.WebhookURL == "" {
+			return nil, errors.New("rule %v: webhook url is required", r.Name)
+		}
+
+		for _, cd := range r.Cooldowns {
+			switch cd.Scope {
+			case CooldownRule, CooldownSeries, CooldownIndexer:
+			default:
+				return nil, errors.New("rule %v: unknown cooldown scope %q", r.Name, cd.Scope)
+			}
+
+			if cd.Window <= 0 {
+				return nil, errors.New("rule %v: cooldown window is required", r.Name)
+			}
+
+			if cd.window() > retain {
+				retain = cd.window()
+			}
+		}
+
+		cr := compiledRule{Rule: r, feeds: stringSet(r.Filter.Feeds), indexers: stringSet(r.Filter.Indexers)}
+
+		var err error
+		if cr.include, err = compileRegexps(r.Filter.Include); err != nil {
+			return nil, errors.Wrap(err, "rule %v", r.Name)
+		}
+
+		if cr.exclude, err = compileRegexps(r.Filter.Exclude); err != nil {
+			return nil, errors.Wrap(err, "rule %v", r.Name)
+		}
+
+		e.rules = append(e.rules, cr)
+	}
+
+	sort.SliceStable(e.rules, func(a, b int) bool {
+		return e.rules[a].Priority > e.rules[b].Priority
+	})
+
+	e.cooldowns = newMemoryCooldowns(retain)
+	e.reserved = make(map[string]int)
+
+	return e, nil
+}
+
+// SetCooldownStore persists the actions counted by cooldowns in store, e.g.
+// a SQLStore, instead of memory. It has to be called before the first
+// evaluation.
+func (e *RuleEngine) SetCooldownStore(store CooldownStore) {
+	e.cooldowns = store
+}
+
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
+
+func compileRegexps(exprs []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid expression %q", expr)
+		}
+
+		out = append(out, re)
+	}
+
+	return out, nil
+}
+
+func (r compiledRule) matches(feed string, item FeedItem) bool {
+	if r.feeds != nil && !r.feeds[feed] {
+		return false
+	}
+
+	if r.indexers != nil && !r.indexers[item.Indexer()] {
+		return false
+	}
+
+	f := r.Filter
+	if item.Seeders() < f.MinSeeders {
+		return false
+	}
+
+	size := item.SizeBytes()
+	if (f.MinSize > 0 && size < f.MinSize) || (f.MaxSize > 0 && size > f.MaxSize) {
+		return false
+	}
+
+	for _, re := range r.exclude {
+		if re.MatchString(item.Title) {
+			return false
+		}
+	}
+
+	if len(r.include) > 0 {
+		matched := false
+		for _, re := range r.include {
+			if re.MatchString(item.Title) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.Categories) > 0 {
+		matched := false
+		for _, want := range f.Categories {
+			for _, cat := range item.Categories() {
+				if cat == want || (!IsCustomCategory(want) && want%1000 == 0 && cat/1000 == want/1000) {
+					matched = true
+				}
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Evaluate runs the actions of the rules matching the item, stopping at the
+// first match unless it has Continue set. It returns the names of the rules
+// whose action ran and the errors of their actions.
+func (e *RuleEngine) Evaluate(ctx context.Context, feed string, item FeedItem) ([]string, error) {
+	var (
+		matched []string
+		errs    []error
+	)
+
+	for _, r := range e.rules {
+		if !r.matches(feed, item) {
+			continue
+		}
+
+		ran, err := e.run(ctx, r, feed, item)
+
+		e.mu.Lock()
+		stats := e.stats[r.Name]
+		stats.Matched++
+		stats.LastMatch = e.clock.Now()
+		if !ran {
+			stats.Suppressed++
+		} else if err != nil {
+			stats.Failed++
+		}
+		e.stats[r.Name] = stats
+		e.mu.Unlock()
+
+		if ran {
+			matched = append(matched, r.Name)
+		}
+
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "rule %v", r.Name))
+		}
+
+		if !r.Continue {
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return matched, errs[0]
+	}
+
+	return matched, nil
+}
+
+// run runs the action of the rule unless it is on cooldown. Successful
+// actions count towards the cooldowns, failed ones don't. The action runs
+// outside of the lock, reserving its place in the cooldowns meanwhile.
+func (e *RuleEngine) run(ctx context.Context, r compiledRule, feed string, item FeedItem) (bool, error) {
+	if len(r.Cooldowns) == 0 {
+		return true, e.handlers[r.Action](ctx, r.Rule, feed, item)
+	}
+
+	now := e.clock.Now()
+
+	keys, ok, err := e.reserve(r, item, now)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	err = e.handlers[r.Action](ctx, r.Rule, feed, item)
+
+	e.cooldownMu.Lock()
+	defer e.cooldownMu.Unlock()
+
+	for _, key := range keys {
+		if e.reserved[key]--; e.reserved[key] == 0 {
+			delete(e.reserved, key)
+		}
+	}
+
+	if err != nil {
+		return true, err
+	}
+
+	for _, key := range keys {
+		if err := e.cooldowns.RecordAction(key, now); err != nil {
+			return true, errors.Wrap(err, "cooldown store error")
+		}
+	}
+
+	return true, nil
+}
+
+// reserve checks the cooldowns of the rule for item, reserving an action in
+// each of them unless one is exhausted. It returns the reserved keys.
+func (e *RuleEngine) reserve(r compiledRule, item FeedItem, now time.Time) ([]string, bool, error) {
+	e.cooldownMu.Lock()
+	defer e.cooldownMu.Unlock()
+
+	var keys []string
+	for _, cd := range r.Cooldowns {
+		key, ok := cd.key(r.Rule, item)
+		if !ok {
+			continue
+		}
+
+		n, err := e.cooldowns.CountActions(key, now.Add(-cd.window()))
+		if err != nil {
+			return nil, false, errors.Wrap(err, "cooldown store error")
+		}
+
+		if n+e.reserved[key] >= cd.max() {
+			return nil, false, nil
+		}
+
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		e.reserved[key]++
+	}
+
+	return keys, true, nil
+}
+
+// Stats returns the statistics of every rule that matched so far, by name.
+func (e *RuleEngine) Stats() map[string]RuleStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	out := make(map[string]RuleStats, len(e.stats))
+	for name, s := range e.stats {
+		out[name] = s
+	}
+
+	return out
+}
+
+type webhookPayload struct {
+	Rule     string `json:"rule"`
+	Feed     string `json:"feed"`
+	Target   string `json:"target,omitempty"`
+	Category string `json:"category,omitempty"`
+	Title    string `json:"title"`
+	GUID     string `json:"guid"`
+	Link     string `json:"link"`
+	Magnet   string `json:"magnet,omitempty"`
+	Size     int64  `json:"size"`
+	Seeders  int    `json:"seeders"`
+	Indexer  string `json:"indexer"`
+}
+
+func postWebhook(ctx context.Context, rule Rule, feed string, item FeedItem) error {
+	link := item.Enclosure.URL
+	if link == "" {
+		link = item.Link
+	}
+
+	payload := webhookPayload{
+		Rule:     rule.Name,
+		Feed:     feed,
+		Target:   rule.Target,
+		Category: rule.Category,
+		Title:    item.Title,
+		GUID:     item.Guid,
+		Link:     link,
+		Magnet:   item.MagnetURI(),
+		Size:     item.SizeBytes(),
+		Seeders:  item.Seeders(),
+		Indexer:  item.Indexer(),
+	}
+
+	return postJSON(ctx, nil, rule.WebhookURL, payload)
+}
@@ -0,0 +1,207 @@
+package jackett_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// ruleItem returns an item of the indexer in category cat.
+func ruleItem(title, indexer string, cat, seeders int, size int64) jackett.FeedItem {
+	return jackett.FeedItem{
+		Title:          title,
+		Jackettindexer: jackett.JackettIndexer{ID: indexer},
+		Category:       []string{strconv.Itoa(cat)},
+		Size:           strconv.FormatInt(size, 10),
+		Attr:           []jackett.ItemAttr{{Name: string(jackett.AttrSeeders), Value: strconv.Itoa(seeders)}},
+	}
+}
+
+// recordGrabs returns handlers recording the rules whose action ran.
+func recordGrabs(ran *[]string) map[jackett.RuleAction]jackett.RuleHandler {
+	return map[jackett.RuleAction]jackett.RuleHandler{
+		jackett.RuleGrab: func(_ context.Context, rule jackett.Rule, _ string, _ jackett.FeedItem) error {
+			*ran = append(*ran, rule.Name)
+			return nil
+		},
+	}
+}
+
+func TestRuleFilter(t *testing.T) {
+	item := ruleItem("Show.S01E01.1080p.WEB", "tracker", 5040, 10, 2<<30)
+
+	tests := []struct {
+		name   string
+		filter jackett.RuleFilter
+		feed   string
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "feed", filter: jackett.RuleFilter{Feeds: []string{"tv", "movies"}}, want: true},
+		{name: "other feed", filter: jackett.RuleFilter{Feeds: []string{"movies"}}},
+		{name: "indexer", filter: jackett.RuleFilter{Indexers: []string{"tracker"}}, want: true},
+		{name: "other indexer", filter: jackett.RuleFilter{Indexers: []string{"other"}}},
+		{name: "include", filter: jackett.RuleFilter{Include: []string{`2160p`, `(?i)s01e\d+`}}, want: true},
+		{name: "not included", filter: jackett.RuleFilter{Include: []string{`2160p`}}},
+		{name: "excluded", filter: jackett.RuleFilter{Include: []string{`1080p`}, Exclude: []string{`WEB`}}},
+		{name: "category", filter: jackett.RuleFilter{Categories: []int{5040}}, want: true},
+		{name: "category group", filter: jackett.RuleFilter{Categories: []int{2000, 5000}}, want: true},
+		{name: "other category", filter: jackett.RuleFilter{Categories: []int{5030}}},
+		{name: "custom category", filter: jackett.RuleFilter{Categories: []int{105000}}},
+		{name: "enough seeders", filter: jackett.RuleFilter{MinSeeders: 10}, want: true},
+		{name: "too few seeders", filter: jackett.RuleFilter{MinSeeders: 11}},
+		{name: "size in range", filter: jackett.RuleFilter{MinSize: 1 << 30, MaxSize: 4 << 30}, want: true},
+		{name: "too small", filter: jackett.RuleFilter{MinSize: 3 << 30}},
+		{name: "too large", filter: jackett.RuleFilter{MaxSize: 1 << 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			rules := []jackett.Rule{{Name: "rule", Filter: tt.filter, Action: jackett.RuleGrab}}
+
+			engine, err := jackett.NewRuleEngine(rules, recordGrabs(&ran))
+			require.NoError(t, err)
+
+			matched, err := engine.Evaluate(context.Background(), "tv", item)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, len(matched) == 1)
+			assert.Equal(t, matched, ran)
+		})
+	}
+}
+
+func TestRulePriority(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []jackett.Rule
+		want  []string
+	}{
+		{
+			name: "highest priority first",
+			rules: []jackett.Rule{
+				{Name: "low", Action: jackett.RuleGrab},
+				{Name: "high", Priority: 10, Action: jackett.RuleGrab},
+			},
+			want: []string{"high"},
+		},
+		{
+			name: "declaration order at the same priority",
+			rules: []jackett.Rule{
+				{Name: "first", Action: jackett.RuleGrab},
+				{Name: "second", Action: jackett.RuleGrab},
+			},
+			want: []string{"first"},
+		},
+		{
+			name: "continue",
+			rules: []jackett.Rule{
+				{Name: "low", Action: jackett.RuleGrab},
+				{Name: "mid", Priority: 5, Action: jackett.RuleGrab},
+				{Name: "high", Priority: 10, Action: jackett.RuleGrab, Continue: true},
+			},
+			want: []string{"high", "mid"},
+		},
+		{
+			name: "skips rules not matching",
+			rules: []jackett.Rule{
+				{Name: "movies", Priority: 10, Action: jackett.RuleGrab, Filter: jackett.RuleFilter{Categories: []int{2000}}},
+				{Name: "tv", Action: jackett.RuleGrab, Filter: jackett.RuleFilter{Categories: []int{5000}}},
+			},
+			want: []string{"tv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+
+			engine, err := jackett.NewRuleEngine(tt.rules, recordGrabs(&ran))
+			require.NoError(t, err)
+
+			item := ruleItem("Show.S01E01.1080p", "tracker", 5040, 10, 1<<30)
+			matched, err := engine.Evaluate(context.Background(), "tv", item)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, matched)
+			assert.Equal(t, tt.want, ran)
+
+			stats := engine.Stats()
+			assert.Len(t, stats, len(tt.want))
+			for _, name := range tt.want {
+				assert.Equal(t, uint64(1), stats[name].Matched)
+			}
+		})
+	}
+}
+
+func TestRuleActionError(t *testing.T) {
+	handlers := map[jackett.RuleAction]jackett.RuleHandler{
+		jackett.RuleGrab: func(context.Context, jackett.Rule, string, jackett.FeedItem) error {
+			return errors.New("client unreachable")
+		},
+	}
+
+	engine, err := jackett.NewRuleEngine([]jackett.Rule{{Name: "grab", Action: jackett.RuleGrab}}, handlers)
+	require.NoError(t, err)
+
+	matched, err := engine.Evaluate(context.Background(), "tv", ruleItem("Show", "tracker", 5000, 1, 1))
+	assert.ErrorContains(t, err, "rule grab: client unreachable")
+	assert.Equal(t, []string{"grab"}, matched)
+	assert.Equal(t, uint64(1), engine.Stats()["grab"].Failed)
+}
+
+func TestNewRuleEngineInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []jackett.Rule
+		err   string
+	}{
+		{
+			name:  "duplicate name",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleGrab}, {Name: "a", Action: jackett.RuleGrab}},
+			err:   "duplicate rule: a",
+		},
+		{
+			name:  "no handler",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleNotify}},
+			err:   `rule a: no handler for action "notify"`,
+		},
+		{
+			name:  "webhook without url",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleWebhook}},
+			err:   "rule a: webhook url is required",
+		},
+		{
+			name:  "invalid expression",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleGrab, Filter: jackett.RuleFilter{Exclude: []string{`(`}}}},
+			err:   `rule a: invalid expression "("`,
+		},
+		{
+			name:  "unknown cooldown scope",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleGrab, Cooldowns: []jackett.Cooldown{{Scope: "feed", Window: 60}}}},
+			err:   `rule a: unknown cooldown scope "feed"`,
+		},
+		{
+			name:  "cooldown without window",
+			rules: []jackett.Rule{{Name: "a", Action: jackett.RuleGrab, Cooldowns: []jackett.Cooldown{{Scope: jackett.CooldownRule}}}},
+			err:   "rule a: cooldown window is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+
+			_, err := jackett.NewRuleEngine(tt.rules, recordGrabs(&ran))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
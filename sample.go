@@ -0,0 +1,97 @@
+package jackett
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// SampleStrategy picks at most n of the items, in the order they are to be
+// shown. It must not modify items.
+type SampleStrategy func(items []FeedItem, n int) []FeedItem
+
+// Sample bounds the items to n, e.g. for display or to stay below the
+// limits of a downstream service, picking them with the strategy. The same
+// items give the same sample.
+func Sample(items []FeedItem, n int, strategy SampleStrategy) []FeedItem {
+	if n <= 0 {
+		return nil
+	}
+
+	return strategy(items, n)
+}
+
+// SampleTopSeeders picks the items with the most seeders, keeping the order
+// of items with as many.
+func SampleTopSeeders(items []FeedItem, n int) []FeedItem {
+	sorted := append([]FeedItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Seeders() > sorted[j].Seeders()
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	return sorted
+}
+
+// SampleRandom returns a strategy picking random items in random order, the
+// same ones for the same seed.
+func SampleRandom(seed int64) SampleStrategy {
+	return func(items []FeedItem, n int) []FeedItem {
+		perm := rand.New(rand.NewSource(seed)).Perm(len(items))
+		if len(perm) > n {
+			perm = perm[:n]
+		}
+
+		picked := make([]FeedItem, 0, len(perm))
+		for _, i := range perm {
+			picked = append(picked, items[i])
+		}
+
+		return picked
+	}
+}
+
+// SamplePerIndexer returns a strategy taking turns between the indexers of
+// the items, in the order they first appear, so a few busy indexers can't
+// crowd out the rest. Within an indexer the items are picked by within.
+func SamplePerIndexer(within SampleStrategy) SampleStrategy {
+	return func(items []FeedItem, n int) []FeedItem {
+		var (
+			order  []string
+			groups = make(map[string][]FeedItem)
+		)
+
+		for _, item := range items {
+			id := item.Jackettindexer.ID
+			if _, ok := groups[id]; !ok {
+				order = append(order, id)
+			}
+
+			groups[id] = append(groups[id], item)
+		}
+
+		ranked := make([][]FeedItem, 0, len(order))
+		for _, id := range order {
+			ranked = append(ranked, within(groups[id], len(groups[id])))
+		}
+
+		var picked []FeedItem
+		for round := 0; len(picked) < n; round++ {
+			added := false
+			for _, list := range ranked {
+				if round < len(list) && len(picked) < n {
+					picked = append(picked, list[round])
+					added = true
+				}
+			}
+
+			if !added {
+				break
+			}
+		}
+
+		return picked
+	}
+}
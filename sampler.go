@@ -0,0 +1,190 @@
+package jackett
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var DefaultSampleInterval = time.Hour
+
+// SwarmSample is the swarm of a release at one point in time.
+type SwarmSample struct {
+	Indexer string
+	GUID    string
+	At      time.Time
+
+	Seeders  int
+	Leechers int
+
+	// false when the release was missing from the results, e.g. because it
+	// was removed from the tracker, the counts are 0 then
+	Found bool
+}
+
+// SampleStore records the samples taken by a Sampler, e.g. SQLStore.
+type SampleStore interface {
+	RecordSample(sample SwarmSample) error
+}
+
+// SampledRelease is a release whose swarm is sampled, found by searching the
+// indexer with Params for an item with the GUID.
+type SampledRelease struct {
+	Client  *Client
+	Indexer string
+	GUID    string
+
+	// a search returning the release, e.g. its title as q
+	Params map[string]string
+}
+
+type SamplerConfig struct {
+	Releases []SampledRelease
+
+	// defaults to DefaultSampleInterval
+	Interval time.Duration
+
+	Store SampleStore
+
+	// clock used for sample times and the interval, defaults to the system clock
+	Clock Clock
+
+	// called with the errors of searches and the store when set
+	OnError func(release SampledRelease, err error)
+}
+
+// Sampler periodically searches for a set of releases, e.g. the uploads of
+// a user, and records the seeders and leechers of each, building a time
+// series of their swarm health. Releases found by the same search are
+// sampled with a single request.
+type Sampler struct {
+	cfg SamplerConfig
+}
+
+func NewSampler(cfg SamplerConfig) *Sampler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSampleInterval
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
+
+	return &Sampler{cfg: cfg}
+}
+
+// Run samples the releases every interval until ctx is done, starting
+// immediately.
+func (s *Sampler) Run(ctx context.Context) error {
+	if s.cfg.Store == nil {
+		return errors.New("sampler has no store")
+	}
+
+	for {
+		s.SampleOnce(ctx)
+
+		select {
+		case <-s.cfg.Clock.After(s.cfg.Interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SampleOnce samples every release once and returns the first error, after
+// trying all of them.
+func (s *Sampler) SampleOnce(ctx context.Context) error {
+	if s.cfg.Store == nil {
+		return errors.New("sampler has no store")
+	}
+
+	var first error
+
+	fail := func(release SampledRelease, err error) {
+		if first == nil {
+			first = err
+		}
+
+		if s.cfg.OnError != nil {
+			s.cfg.OnError(release, err)
+		}
+	}
+
+	for _, group := range s.searches() {
+		r := group[0]
+
+		rss, err := r.Client.GetTorrentsCtx(ctx, r.Indexer, r.Params)
+		if err != nil {
+			for _, release := range group {
+				fail(release, errors.Wrap(err, "could not sample %v", release.GUID))
+			}
+
+			continue
+		}
+
+		found := make(map[string]FeedItem, len(rss.Channel.Item))
+		for _, item := range rss.Channel.Item {
+			found[itemKey(item)] = item
+		}
+
+		now := s.cfg.Clock.Now()
+		for _, release := range group {
+			sample := SwarmSample{Indexer: release.Indexer, GUID: release.GUID, At: now}
+
+			if item, ok := found[release.GUID]; ok {
+				sample.Found = true
+				sample.Seeders = item.Seeders()
+				sample.Leechers = leechers(item)
+			}
+
+			if err := s.cfg.Store.RecordSample(sample); err != nil {
+				fail(release, errors.Wrap(err, "could not record sample of %v", release.GUID))
+			}
+		}
+	}
+
+	return first
+}
+
+// searches groups the releases by the search finding them, in the order of
+// their first release.
+func (s *Sampler) searches() [][]SampledRelease {
+	type search struct {
+		client *Client
+		key    string
+	}
+
+	index := make(map[search]int)
+
+	var groups [][]SampledRelease
+	for _, r := range s.cfg.Releases {
+		key := search{client: r.Client, key: cacheKey(r.Indexer, r.Params)}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], r)
+	}
+
+	return groups
+}
+
+// leechers returns the leechers attribute of the item, or the peers that
+// aren't seeders when there is none.
+func leechers(item FeedItem) int {
+	if n, err := strconv.Atoi(item.GetAttr(AttrLeechers)); err == nil {
+		return n
+	}
+
+	if n := item.Peers() - item.Seeders(); n > 0 {
+		return n
+	}
+
+	return 0
+}
@@ -0,0 +1,161 @@
+package jackett
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// Search types, the t param of the torznab api.
+const (
+	SearchTypeSearch = "search"
+	SearchTypeTV     = "tvsearch"
+	SearchTypeMovie  = "movie"
+	SearchTypeMusic  = "music"
+	SearchTypeAudio  = "audio"
+	SearchTypeBook   = "book"
+)
+
+// SearchRequest is a search of the torznab api with typed params, turned into
+// the params of GetTorrentsCtx by Build. Zero values are left out.
+type SearchRequest struct {
+	// defaults to SearchTypeSearch
+	Type string
+
+	Query      string
+	Categories []int
+
+	// results per page and the index of the first result, within the
+	// limits of the caps of the indexer
+	Limit  int
+	Offset int
+
+	// ids of the content in external databases, sent as imdbid, tmdbid,
+	// tvdbid, tvmazeid, rid and traktid
+	IDs ExternalIDs
+
+	// tv searches
+	Season  int
+	Episode int
+
+	// movie searches
+	Year  int
+	Genre string
+
+	// music searches
+	Artist string
+	Album  string
+
+	// book searches
+	Author string
+	Title  string
+
+	// ask for all torznab attributes instead of the default set
+	Extended bool
+
+	// params without a field, e.g. extensions of a tracker, sent as is. The
+	// fields take precedence over them. See Validate.
+	Extra map[string]string
+
+	// path of the torznab api in DirectMode for this search, overriding
+	// Config.PathTemplate, e.g. /tv/api for trackers with split apis
+	PathTemplate string
+}
+
+// Build returns the torznab params of the request.
+func (r SearchRequest) Build() map[string]string {
+	opts := make(map[string]string, len(r.Extra)+1)
+	for k, v := range r.Extra {
+		opts[k] = v
+	}
+
+	opts["t"] = r.Type
+	if r.Type == "" {
+		opts["t"] = SearchTypeSearch
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			opts[key] = value
+		}
+	}
+
+	setInt := func(key string, value int) {
+		if value > 0 {
+			opts[key] = strconv.Itoa(value)
+		}
+	}
+
+	set("q", strings.TrimSpace(r.Query))
+
+	if len(r.Categories) > 0 {
+		cats := make([]string, 0, len(r.Categories))
+		for _, cat := range r.Categories {
+			cats = append(cats, strconv.Itoa(cat))
+		}
+
+		opts["cat"] = strings.Join(cats, ",")
+	}
+
+	setInt("limit", r.Limit)
+	setInt("offset", r.Offset)
+
+	set("imdbid", normalizeIMDBID(r.IDs.IMDB))
+	setInt("tmdbid", r.IDs.TMDB)
+	setInt("tvdbid", r.IDs.TVDB)
+	setInt("tvmazeid", r.IDs.TVMaze)
+	setInt("rid", r.IDs.TVRage)
+	setInt("traktid", r.IDs.Trakt)
+
+	setInt("season", r.Season)
+	setInt("ep", r.Episode)
+	setInt("year", r.Year)
+	set("genre", r.Genre)
+	set("artist", r.Artist)
+	set("album", r.Album)
+	set("author", r.Author)
+	set("title", r.Title)
+
+	if r.Extended {
+		opts["extended"] = "1"
+	}
+
+	return opts
+}
+
+// Validate checks the request against the caps of the indexer, see
+// Client.ValidateSearch. Extra params have to be listed in the supported
+// params of the search mode, unless every mode accepts them, e.g. offset.
+func (r SearchRequest) Validate(caps Caps) error {
+	opts := r.Build()
+
+	if mode, ok := searchModes[opts["t"]]; ok && caps.SupportsMode(mode) {
+		var unsupported []string
+		for k := range r.Extra {
+			if !genericParams[k] && !caps.Supports(mode, k) {
+				unsupported = append(unsupported, "param "+k)
+			}
+		}
+
+		if len(unsupported) > 0 {
+			sort.Strings(unsupported)
+			return errors.Wrap(ErrUnsupportedSearch, "%v does not support %v", opts["t"], strings.Join(unsupported, ", "))
+		}
+	}
+
+	return validateSearch(caps, opts)
+}
+
+// SearchIndexerCtx searches the indexer with the params of req, see
+// GetTorrentsCtx.
+func (c *Client) SearchIndexerCtx(ctx context.Context, indexer string, req SearchRequest) (Rss, error) {
+	var path string
+	if c.cfg.DirectMode {
+		path = req.PathTemplate
+	}
+
+	return c.getTorrentsCtx(ctx, indexer, path, req.Build())
+}
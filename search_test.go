@@ -0,0 +1,58 @@
+package jackett_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// TestSearchPathTemplate expects the path of a search to apply to that
+// search only, not to the other requests made with its context.
+func TestSearchPathTemplate(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path+" "+r.URL.Query().Get("t"))
+		mu.Unlock()
+
+		if r.URL.Query().Get("t") == "caps" {
+			fmt.Fprint(w, testCaps)
+			return
+		}
+
+		fmt.Fprintf(w, testFeed, "release", "release", "http://dl.invalid", "release", "http://dl.invalid", "release")
+	}))
+	defer srv.Close()
+
+	client := jackett.NewClient(jackett.Config{
+		Host:         srv.URL,
+		APIKey:       "apikey",
+		DirectMode:   true,
+		DisableRetry: true,
+	})
+
+	ctx := context.Background()
+
+	_, err := client.Search().Query("release").Path("/tv/api").Do(ctx)
+	require.NoError(t, err)
+
+	_, err = client.GetTorrentsCtx(ctx, "tracker", map[string]string{"t": "search", "q": "release"})
+	require.NoError(t, err)
+
+	_, err = client.GetCapsCtx(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/tv/api search", "/api search", "/api caps"}, paths)
+}
@@ -0,0 +1,101 @@
+package jackett
+
+import "context"
+
+// SearchBuilder composes a SearchRequest, e.g.
+//
+//	client.Search().Query("ubuntu").Categories(2000, 5040).Limit(50).Indexer("iptorrents").Do(ctx)
+//
+// Each method sets a field and returns the builder. A builder is not safe for
+// concurrent use.
+type SearchBuilder struct {
+	client  *Client
+	indexer string
+	req     SearchRequest
+}
+
+// Search starts a search of the "all" indexer, unless Indexer is set.
+func (c *Client) Search() *SearchBuilder {
+	return &SearchBuilder{client: c, indexer: "all"}
+}
+
+func (b *SearchBuilder) Indexer(indexer string) *SearchBuilder {
+	b.indexer = indexer
+	return b
+}
+
+// Type sets the search type, one of the SearchType constants.
+func (b *SearchBuilder) Type(t string) *SearchBuilder {
+	b.req.Type = t
+	return b
+}
+
+func (b *SearchBuilder) Query(q string) *SearchBuilder {
+	b.req.Query = q
+	return b
+}
+
+// Categories adds to the categories searched.
+func (b *SearchBuilder) Categories(cats ...int) *SearchBuilder {
+	b.req.Categories = append(b.req.Categories, cats...)
+	return b
+}
+
+func (b *SearchBuilder) Limit(n int) *SearchBuilder {
+	b.req.Limit = n
+	return b
+}
+
+func (b *SearchBuilder) Offset(n int) *SearchBuilder {
+	b.req.Offset = n
+	return b
+}
+
+func (b *SearchBuilder) IDs(ids ExternalIDs) *SearchBuilder {
+	b.req.IDs = ids
+	return b
+}
+
+// Episode sets the season and episode of a tv search, 0 for season packs.
+func (b *SearchBuilder) Episode(season, episode int) *SearchBuilder {
+	b.req.Season, b.req.Episode = season, episode
+	return b
+}
+
+func (b *SearchBuilder) Year(year int) *SearchBuilder {
+	b.req.Year = year
+	return b
+}
+
+func (b *SearchBuilder) Extended() *SearchBuilder {
+	b.req.Extended = true
+	return b
+}
+
+// Param sets a param without a field of SearchRequest, see
+// SearchRequest.Extra.
+func (b *SearchBuilder) Param(key, value string) *SearchBuilder {
+	if b.req.Extra == nil {
+		b.req.Extra = make(map[string]string)
+	}
+
+	b.req.Extra[key] = value
+	return b
+}
+
+// Path sets the path of the torznab api in DirectMode for this search, see
+// SearchRequest.PathTemplate.
+func (b *SearchBuilder) Path(tmpl string) *SearchBuilder {
+	b.req.PathTemplate = tmpl
+	return b
+}
+
+// Request returns the request composed so far.
+func (b *SearchBuilder) Request() SearchRequest {
+	return b.req
+}
+
+// Do runs the search, see GetTorrentsCtx.
+func (b *SearchBuilder) Do(ctx context.Context) (Rss, error) {
+	return b.client.SearchIndexerCtx(ctx, b.indexer, b.req)
+}
@@ -0,0 +1,131 @@
+package jackett
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// ServerConfig is the configuration of a Jackett server as reported by its
+// admin api.
+type ServerConfig struct {
+	AppVersion string `json:"app_version"`
+
+	Port             int    `json:"port"`
+	External         bool   `json:"external"`
+	BasePathOverride string `json:"basepathoverride"`
+	BaseURLOverride  string `json:"baseurloverride"`
+	UpdateDisabled   bool   `json:"updatedisabled"`
+	Prerelease       bool   `json:"prerelease"`
+	Logging          bool   `json:"logging"`
+	BlackholeDir     string `json:"blackholedir"`
+
+	CacheEnabled bool `json:"cache_enabled"`
+
+	// seconds
+	CacheTTL int `json:"cache_ttl"`
+
+	CacheMaxResultsPerIndexer int    `json:"cache_max_results_per_indexer"`
+	FlareSolverrURL           string `json:"flaresolverrurl"`
+	ProxyType                 int    `json:"proxy_type"`
+	ProxyURL                  string `json:"proxy_url"`
+
+	// warnings shown on the dashboard, e.g. about an outdated runtime
+	Notices []string `json:"notices"`
+}
+
+// Version returns the parsed AppVersion.
+func (sc ServerConfig) Version() (Version, error) {
+	return ParseVersion(sc.AppVersion)
+}
+
+// GetServerConfigCtx returns the configuration of the Jackett server through
+// the admin api, logging in with Config.AdminPassword when the dashboard is
+// protected.
+func (c *Client) GetServerConfigCtx(ctx context.Context) (ServerConfig, error) {
+	var sc ServerConfig
+
+	reqUrl, _ := url.JoinPath(c.host(), "/api/v2.0/server/config")
+	if err := c.doAdminUrl(ctx, http.MethodGet, reqUrl, nil, &sc); err != nil {
+		return sc, errors.Wrap(err, "could not get server config")
+	}
+
+	return sc, nil
+}
+
+// Version is a dotted version number, e.g. of Jackett 0.21.1000.
+type Version []int
+
+// ParseVersion parses a dotted version, ignoring a leading v and suffixes
+// such as -beta or +build.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+
+	if s == "" {
+		return nil, errors.New("invalid version: empty")
+	}
+
+	var v Version
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, errors.New("invalid version: %v", s)
+		}
+
+		v = append(v, n)
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than o.
+// Missing components count as 0, so 0.21 equals 0.21.0.
+func (v Version) Compare(o Version) int {
+	for i := 0; i < len(v) || i < len(o); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+
+		if i < len(o) {
+			b = o[i]
+		}
+
+		if a != b {
+			if a < b {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// AtLeast reports whether v is o or higher, e.g. to gate a feature on the
+// Jackett version introducing it. It is false when o is invalid.
+func (v Version) AtLeast(o string) bool {
+	want, err := ParseVersion(o)
+	if err != nil {
+		return false
+	}
+
+	return v.Compare(want) >= 0
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,80 @@
+package jackett_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    jackett.Version
+		wantErr bool
+	}{
+		{in: "0.21.1000", want: jackett.Version{0, 21, 1000}},
+		{in: "v0.22.5", want: jackett.Version{0, 22, 5}},
+		{in: " 1.2 ", want: jackett.Version{1, 2}},
+		{in: "0.21.1000-beta", want: jackett.Version{0, 21, 1000}},
+		{in: "0.21.1000+build.5", want: jackett.Version{0, 21, 1000}},
+		{in: "2", want: jackett.Version{2}},
+		{in: "", wantErr: true},
+		{in: "v", wantErr: true},
+		{in: "0.x.1", wantErr: true},
+		{in: "0..1", wantErr: true},
+		{in: "0.-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := jackett.ParseVersion(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v)
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "0.21.1000", b: "0.21.1000", want: 0},
+		{a: "0.21", b: "0.21.0", want: 0},
+		{a: "0.21.0.0", b: "0.21", want: 0},
+		{a: "0.21.999", b: "0.21.1000", want: -1},
+		{a: "0.22.0", b: "0.21.1000", want: 1},
+		{a: "1.0", b: "0.99.99", want: 1},
+		{a: "0.21", b: "0.21.1", want: -1},
+		{a: "0.21.1", b: "0.21", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := jackett.ParseVersion(tt.a)
+			require.NoError(t, err)
+
+			b, err := jackett.ParseVersion(tt.b)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, a.Compare(b))
+			assert.Equal(t, -tt.want, b.Compare(a))
+			assert.Equal(t, tt.want >= 0, a.AtLeast(tt.b))
+		})
+	}
+}
+
+func TestVersionAtLeastInvalid(t *testing.T) {
+	v := jackett.Version{0, 21, 1000}
+
+	assert.False(t, v.AtLeast("not a version"))
+	assert.Equal(t, "0.21.1000", v.String())
+}
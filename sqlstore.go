@@ -0,0 +1,323 @@
+package jackett
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+// sqlMigrations upgrade the tables of a version to the next one, keyed by
+// the version they upgrade from.
+var sqlMigrations = map[int][]string{}
+
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS jackett_seen (
+		feed TEXT NOT NULL,
+		key TEXT NOT NULL,
+		PRIMARY KEY (feed, key)
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackett_blocklist (
+		key TEXT NOT NULL PRIMARY KEY,
+		reason TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackett_cache (
+		key TEXT NOT NULL PRIMARY KEY,
+		value BLOB NOT NULL,
+		expires INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackett_grabs (
+		id INTEGER PRIMARY KEY,
+		value BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackett_watermarks (
+		feed TEXT NOT NULL PRIMARY KEY,
+		value BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS jackett_actions (
+		key TEXT NOT NULL,
+		at INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS jackett_actions_key_at ON jackett_actions (key, at)`,
+	`CREATE TABLE IF NOT EXISTS jackett_samples (
+		indexer TEXT NOT NULL,
+		guid TEXT NOT NULL,
+		at INTEGER NOT NULL,
+		seeders INTEGER NOT NULL,
+		leechers INTEGER NOT NULL,
+		found INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS jackett_samples_guid_at ON jackett_samples (guid, at)`,
+}
+
+// SQLStore implements SeenStore, Blocklist, Cache, GrabQueue, WatermarkStore,
+// CooldownStore, BudgetStore and SampleStore on top of a database/sql handle.
+// The statements are written for SQLite, bring your own driver, e.g.
+// modernc.org/sqlite or github.com/mattn/go-sqlite3. See the boltstore
+// package for a store needing no driver.
+type SQLStore struct {
+	db    *sql.DB
+	codec Codec
+}
+
+// NewSQLStore creates the tables of the store if needed and migrates tables
+// of older versions to SQLSchemaVersion. Databases of newer versions fail
+// with ErrUnsupportedVersion. A nil codec defaults to JSONCodec.
+func NewSQLStore(db *sql.DB, codec Codec) (*SQLStore, error) {
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS jackett_schema_version (version INTEGER NOT NULL)`); err != nil {
+		return nil, errors.Wrap(err, "could not create schema")
+	}
+
+	version := SQLSchemaVersion
+	err := db.QueryRow(`SELECT version FROM jackett_schema_version`).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err := db.Exec(`INSERT INTO jackett_schema_version (version) VALUES (?)`, SQLSchemaVersion); err != nil {
+			return nil, errors.Wrap(err, "could not record schema version")
+		}
+	case err != nil:
+		return nil, errors.Wrap(err, "could not read schema version")
+	}
+
+	if err := checkVersion("sql schema", version, SQLSchemaVersion); err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range sqlSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, errors.Wrap(err, "could not create schema")
+		}
+	}
+
+	if err := migrateSQL(db, version); err != nil {
+		return nil, err
+	}
+
+	return &SQLStore{db: db, codec: codec}, nil
+}
+
+// migrateSQL upgrades the tables from version to SQLSchemaVersion, one
+// version per transaction.
+func migrateSQL(db *sql.DB, version int) error {
+	for ; version < SQLSchemaVersion; version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return errors.Wrap(err, "could not migrate schema")
+		}
+
+		for _, stmt := range sqlMigrations[version] {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "could not migrate schema from version %d", version)
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE jackett_schema_version SET version = ?`, version+1); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "could not record schema version")
+		}
+
+		if err := tx.Commit(); err != nil {
+			return errors.Wrap(err, "could not migrate schema from version %d", version)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLStore) Seen(feed, key string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM jackett_seen WHERE feed = ? AND key = ?`, feed, key).Scan(&n)
+	return n > 0, err
+}
+
+func (s *SQLStore) MarkSeen(feed, key string) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO jackett_seen (feed, key) VALUES (?, ?)`, feed, key)
+	return err
+}
+
+func (s *SQLStore) Blocked(key string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM jackett_blocklist WHERE key = ?`, key).Scan(&n)
+	return n > 0, err
+}
+
+func (s *SQLStore) Block(key, reason string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO jackett_blocklist (key, reason) VALUES (?, ?)`, key, reason)
+	return err
+}
+
+func (s *SQLStore) Get(key string) (CachedResult, bool, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT value FROM jackett_cache WHERE key = ?`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return CachedResult{}, false, nil
+	} else if err != nil {
+		return CachedResult{}, false, err
+	}
+
+	var result CachedResult
+	if err := s.codec.Unmarshal(data, &result); err != nil {
+		return CachedResult{}, false, errors.Wrap(err, "could not decode cached result: %v", key)
+	}
+
+	return result, true, nil
+}
+
+func (s *SQLStore) Set(result CachedResult) error {
+	data, err := s.codec.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "could not encode cached result: %v", result.Key)
+	}
+
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO jackett_cache (key, value, expires) VALUES (?, ?, ?)`,
+		result.Key, data, result.Expires.Unix())
+	return err
+}
+
+func (s *SQLStore) Delete(key string) error {
+	_, err := s.db.Exec(`DELETE FROM jackett_cache WHERE key = ?`, key)
+	return err
+}
+
+// PurgeExpired deletes the cached results expired before now.
+func (s *SQLStore) PurgeExpired(now time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM jackett_cache WHERE expires < ?`, now.Unix())
+	return err
+}
+
+func (s *SQLStore) Push(item FeedItem) error {
+	data, err := s.codec.Marshal(item)
+	if err != nil {
+		return errors.Wrap(err, "could not encode item: %v", item.Title)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO jackett_grabs (value) VALUES (?)`, data)
+	return err
+}
+
+func (s *SQLStore) Pop() (FeedItem, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return FeedItem{}, false, err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		id   int64
+		data []byte
+	)
+
+	err = tx.QueryRow(`SELECT id, value FROM jackett_grabs ORDER BY id LIMIT 1`).Scan(&id, &data)
+	if err == sql.ErrNoRows {
+		return FeedItem{}, false, nil
+	} else if err != nil {
+		return FeedItem{}, false, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM jackett_grabs WHERE id = ?`, id); err != nil {
+		return FeedItem{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FeedItem{}, false, err
+	}
+
+	// undecodable items are dropped rather than blocking the queue
+	var item FeedItem
+	if err := s.codec.Unmarshal(data, &item); err != nil {
+		return FeedItem{}, false, errors.Wrap(err, "could not decode queued item")
+	}
+
+	return item, true, nil
+}
+
+func (s *SQLStore) Watermark(feed string) (Watermark, bool, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT value FROM jackett_watermarks WHERE feed = ?`, feed).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Watermark{}, false, nil
+	} else if err != nil {
+		return Watermark{}, false, err
+	}
+
+	var mark Watermark
+	if err := s.codec.Unmarshal(data, &mark); err != nil {
+		return Watermark{}, false, errors.Wrap(err, "could not decode watermark: %v", feed)
+	}
+
+	return mark, true, nil
+}
+
+func (s *SQLStore) SetWatermark(feed string, mark Watermark) error {
+	data, err := s.codec.Marshal(mark)
+	if err != nil {
+		return errors.Wrap(err, "could not encode watermark: %v", feed)
+	}
+
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO jackett_watermarks (feed, value) VALUES (?, ?)`, feed, data)
+	return err
+}
+
+func (s *SQLStore) CountActions(key string, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM jackett_actions WHERE key = ? AND at >= ?`, key, since.UnixNano()).Scan(&n)
+	return n, err
+}
+
+func (s *SQLStore) RecordAction(key string, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO jackett_actions (key, at) VALUES (?, ?)`, key, at.UnixNano())
+	return err
+}
+
+// PurgeActions deletes the actions recorded before t, which no longer count
+// towards cooldowns shorter than now - t.
+func (s *SQLStore) PurgeActions(t time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM jackett_actions WHERE at < ?`, t.UnixNano())
+	return err
+}
+
+func (s *SQLStore) RecordSample(sample SwarmSample) error {
+	_, err := s.db.Exec(`INSERT INTO jackett_samples (indexer, guid, at, seeders, leechers, found) VALUES (?, ?, ?, ?, ?, ?)`,
+		sample.Indexer, sample.GUID, sample.At.UnixNano(), sample.Seeders, sample.Leechers, sample.Found)
+	return err
+}
+
+// Samples returns the samples of the release with the guid taken at or
+// after since, oldest first.
+func (s *SQLStore) Samples(guid string, since time.Time) ([]SwarmSample, error) {
+	rows, err := s.db.Query(`SELECT indexer, guid, at, seeders, leechers, found FROM jackett_samples WHERE guid = ? AND at >= ? ORDER BY at`, guid, since.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var samples []SwarmSample
+	for rows.Next() {
+		var (
+			sample SwarmSample
+			at     int64
+		)
+
+		if err := rows.Scan(&sample.Indexer, &sample.GUID, &at, &sample.Seeders, &sample.Leechers, &sample.Found); err != nil {
+			return nil, err
+		}
+
+		sample.At = time.Unix(0, at)
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
+
+// PurgeSamples deletes the samples taken before t.
+func (s *SQLStore) PurgeSamples(t time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM jackett_samples WHERE at < ?`, t.UnixNano())
+	return err
+}
@@ -0,0 +1,30 @@
+package jackett
+
+// SeenStore remembers the items a watcher delivered per feed, so restarts
+// don't deliver them again.
+type SeenStore interface {
+	Seen(feed, key string) (bool, error)
+	MarkSeen(feed, key string) error
+}
+
+// Blocklist holds items that must not be delivered again, e.g. fakes or
+// releases that failed to download.
+type Blocklist interface {
+	Blocked(key string) (bool, error)
+	Block(key, reason string) error
+}
+
+// Cache persists search results behind the in-memory cache of a client.
+type Cache interface {
+	Get(key string) (CachedResult, bool, error)
+	Set(result CachedResult) error
+	Delete(key string) error
+}
+
+// GrabQueue holds items waiting to be grabbed, first in first out.
+type GrabQueue interface {
+	Push(item FeedItem) error
+
+	// Pop returns false when the queue is empty.
+	Pop() (FeedItem, bool, error)
+}
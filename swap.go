@@ -0,0 +1,113 @@
+package jackett
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// swapLogger is a Logger whose target can be replaced while in use.
+type swapLogger struct {
+	v atomic.Value
+}
+
+// loggerBox keeps the dynamic type stored in the atomic.Value constant.
+type loggerBox struct {
+	Logger
+}
+
+func newSwapLogger(l Logger) *swapLogger {
+	s := &swapLogger{}
+	s.store(l)
+	return s
+}
+
+func (s *swapLogger) store(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	s.v.Store(loggerBox{l})
+}
+
+func (s *swapLogger) load() Logger {
+	return s.v.Load().(loggerBox).Logger
+}
+
+func (s *swapLogger) Debug(msg string, args ...interface{}) { s.load().Debug(msg, args...) }
+func (s *swapLogger) Info(msg string, args ...interface{})  { s.load().Info(msg, args...) }
+func (s *swapLogger) Warn(msg string, args ...interface{})  { s.load().Warn(msg, args...) }
+func (s *swapLogger) Error(msg string, args ...interface{}) { s.load().Error(msg, args...) }
+
+// SetLogger replaces the logger of the client, e.g. to change the verbosity
+// of a long running service. It is safe to call while requests are running,
+// nil discards the logs.
+func (c *Client) SetLogger(l Logger) {
+	c.log.store(l)
+}
+
+// Timeout returns the request timeout of the client.
+func (c *Client) Timeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.timeout))
+}
+
+// SetTimeout replaces the request timeout, applying to the requests started
+// after it. It is safe to call while requests are running. The timeout of a
+// Config.HTTPClient is left alone, only the methods without a Ctx suffix
+// pick it up then.
+func (c *Client) SetTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultTimeout
+	}
+
+	atomic.StoreInt64(&c.timeout, int64(d))
+}
+
+type limiterBox struct {
+	RateLimiter
+}
+
+// SetRateLimiter replaces the rate limiter of the client, nil disables rate
+// limiting. It is safe to call while requests are running, requests waiting
+// for a token keep waiting on the previous limiter.
+func (c *Client) SetRateLimiter(l RateLimiter) {
+	c.limiter.Store(limiterBox{l})
+}
+
+func (c *Client) rateLimiter() RateLimiter {
+	box, _ := c.limiter.Load().(limiterBox)
+	return box.RateLimiter
+}
+
+// do sends req, bounded by the timeout of the client unless the caller
+// brought their own http client. Like http.Client.Timeout the timeout covers
+// reading the body, it is released when the body is closed.
+func (c *Client) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if c.cfg.HTTPClient != nil {
+		return client.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.Timeout())
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,449 @@
+// Package torznabserver serves a single torznab endpoint searching several
+// jackett clients, e.g. a Jackett next to standalone torznab trackers, and
+// merging their results, so Sonarr and Radarr need one indexer for all of
+// them.
+//
+//	GET ?t=caps                      caps merged from every backend
+//	GET ?t=search|tvsearch|movie...  results of every backend, deduplicated
+package torznabserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// DefaultTimeout bounds the search of each backend.
+var DefaultTimeout = 30 * time.Second
+
+// Backend is a client searched by the server.
+type Backend struct {
+	Client *jackett.Client
+
+	// indexer searched, defaults to "all"
+	Indexer string
+}
+
+type Config struct {
+	Backends []Backend
+
+	// api keys accepted in the apikey param or X-Api-Key header, empty
+	// disables authentication
+	APIKeys []string
+
+	// title of the server in its caps and feeds
+	Title string
+
+	// bounds the search of each backend, defaults to DefaultTimeout. Backends
+	// failing or timing out are left out of the results.
+	Timeout time.Duration
+}
+
+// Handler is the torznab endpoint, mount it at the path consumers expect,
+// e.g. /api.
+type Handler struct {
+	cfg Config
+
+	mu   sync.Mutex
+	caps *jackett.Caps
+}
+
+func New(cfg Config) *Handler {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	if cfg.Title == "" {
+		cfg.Title = "go-jackett"
+	}
+
+	for i := range cfg.Backends {
+		if cfg.Backends[i].Indexer == "" {
+			cfg.Backends[i].Indexer = "all"
+		}
+	}
+
+	return &Handler{cfg: cfg}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized, jackett.ErrCodeIncorrectCredentials, "Invalid API Key")
+		return
+	}
+
+	switch t := r.URL.Query().Get("t"); t {
+	case "caps":
+		h.serveCaps(w, r)
+	case "search", "tvsearch", "tv-search", "movie", "movie-search", "music", "audio", "book":
+		h.serveSearch(w, r)
+	case "":
+		writeError(w, http.StatusOK, jackett.ErrCodeMissingParameter, "Missing parameter t")
+	default:
+		writeError(w, http.StatusOK, jackett.ErrCodeNoSuchFunction, "Function Not Available: "+t)
+	}
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if len(h.cfg.APIKeys) == 0 {
+		return true
+	}
+
+	key := r.Header.Get("X-Api-Key")
+	if key == "" {
+		key = r.URL.Query().Get("apikey")
+	}
+
+	for _, k := range h.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveSearch searches every backend concurrently with the params of the
+// request and serves the merged results, the newest first, cut to its limit.
+func (h *Handler) serveSearch(w http.ResponseWriter, r *http.Request) {
+	params := make(map[string]string)
+	for k, v := range r.URL.Query() {
+		if k != "apikey" && len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+
+	type result struct {
+		items []jackett.FeedItem
+		err   error
+	}
+
+	results := make([]result, len(h.cfg.Backends))
+
+	var wg sync.WaitGroup
+	for i, b := range h.cfg.Backends {
+		wg.Add(1)
+		go func(i int, b Backend) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), h.cfg.Timeout)
+			defer cancel()
+
+			rss, err := b.Client.GetTorrentsCtx(ctx, b.Indexer, params)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+
+			results[i].items = rewriteLinks(b.Client, rss.Channel.Item)
+		}(i, b)
+	}
+
+	wg.Wait()
+
+	var (
+		items  [][]jackett.FeedItem
+		failed error
+	)
+
+	for _, res := range results {
+		if res.err != nil {
+			failed = res.err
+			continue
+		}
+
+		items = append(items, res.items)
+	}
+
+	if len(items) == 0 && failed != nil {
+		writeError(w, http.StatusBadGateway, jackett.ErrCodeUnknown, failed.Error())
+		return
+	}
+
+	merged := Merge(items...)
+	if limit, _ := strconv.Atoi(params["limit"]); limit > 0 && limit < len(merged) {
+		merged = merged[:limit]
+	}
+
+	writeXML(w, jackett.NewFeed(h.cfg.Title, requestURL(r), merged))
+}
+
+// Merge merges the results of several searches, the newest first. Results
+// of the same torrent, by info hash, or with the same guid are merged into
+// the one with the most seeders.
+func Merge(results ...[]jackett.FeedItem) []jackett.FeedItem {
+	var (
+		merged []jackett.FeedItem
+		seen   = make(map[string]int)
+	)
+
+	for _, items := range results {
+		for _, item := range items {
+			key := dedupKey(item)
+			if i, ok := seen[key]; ok {
+				if item.Seeders() > merged[i].Seeders() {
+					merged[i] = item
+				}
+
+				continue
+			}
+
+			seen[key] = len(merged)
+			merged = append(merged, item)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishDate().After(merged[j].PublishDate())
+	})
+
+	return merged
+}
+
+func dedupKey(item jackett.FeedItem) string {
+	if hash := item.InfoHash(); hash != "" {
+		return "btih:" + strings.ToLower(hash)
+	}
+
+	if item.Guid != "" {
+		return "guid:" + item.Guid
+	}
+
+	return "link:" + item.Link
+}
+
+// rewriteLinks passes the download urls of the items through the
+// RewriteEnclosure of their client, e.g. so links of an internal Jackett
+// point at its public address. Items whose links can't be rewritten are
+// dropped rather than leaking them.
+func rewriteLinks(c *jackett.Client, items []jackett.FeedItem) []jackett.FeedItem {
+	out := make([]jackett.FeedItem, 0, len(items))
+	for _, item := range items {
+		enclosure, err := c.EnclosureURL(item.Enclosure.URL)
+		if err != nil {
+			continue
+		}
+
+		link, err := c.EnclosureURL(item.Link)
+		if err != nil {
+			continue
+		}
+
+		item.Enclosure.URL, item.Link = enclosure, link
+		out = append(out, item)
+	}
+
+	return out
+}
+
+// serveCaps serves the caps of the backends merged, fetched once.
+func (h *Handler) serveCaps(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.caps == nil {
+		var all []jackett.Caps
+		for _, b := range h.cfg.Backends {
+			ctx, cancel := context.WithTimeout(r.Context(), h.cfg.Timeout)
+			caps, err := b.Client.GetCapsForIndexerCtx(ctx, b.Indexer)
+			cancel()
+
+			if err != nil {
+				continue
+			}
+
+			all = append(all, caps)
+		}
+
+		if len(all) == 0 && len(h.cfg.Backends) > 0 {
+			writeError(w, http.StatusBadGateway, jackett.ErrCodeUnknown, "no backend is reachable")
+			return
+		}
+
+		caps := MergeCaps(all...)
+		caps.ServerTitle = h.cfg.Title
+		h.caps = &caps
+	}
+
+	writeXML(w, newCapsDocument(*h.caps))
+}
+
+// MergeCaps merges the caps of several servers: the search modes any of
+// them offers with the params any of them supports, the categories of all
+// of them and the highest limits.
+func MergeCaps(all ...jackett.Caps) jackett.Caps {
+	merged := jackett.Caps{Modes: make(map[string]jackett.SearchModeCaps)}
+
+	categories := make(map[int]int)
+	for _, caps := range all {
+		if caps.Limits.Default > merged.Limits.Default {
+			merged.Limits.Default = caps.Limits.Default
+		}
+
+		if caps.Limits.Max > merged.Limits.Max {
+			merged.Limits.Max = caps.Limits.Max
+		}
+
+		for mode, mc := range caps.Modes {
+			m := merged.Modes[mode]
+			m.Params = union(m.Params, mc.Params)
+			merged.Modes[mode] = m
+		}
+
+		for _, cat := range caps.Categories {
+			i, ok := categories[cat.ID]
+			if !ok {
+				categories[cat.ID] = len(merged.Categories)
+				merged.Categories = append(merged.Categories, jackett.CapsCategory{ID: cat.ID, Name: cat.Name})
+				i = len(merged.Categories) - 1
+			}
+
+			for _, sub := range cat.Subcats {
+				if !hasCategory(merged.Categories[i].Subcats, sub.ID) {
+					merged.Categories[i].Subcats = append(merged.Categories[i].Subcats, sub)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
+func union(a, b []string) []string {
+	for _, s := range b {
+		found := false
+		for _, t := range a {
+			if s == t {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			a = append(a, s)
+		}
+	}
+
+	return a
+}
+
+func hasCategory(cats []jackett.CapsCategory, id int) bool {
+	for _, c := range cats {
+		if c.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capsDocument is the torznab caps document of merged caps.
+type capsDocument struct {
+	XMLName xml.Name `xml:"caps"`
+	Server  struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Default int `xml:"default,attr"`
+		Max     int `xml:"max,attr"`
+	} `xml:"limits"`
+	Searching struct {
+		Modes []capsMode
+	} `xml:"searching"`
+	Categories struct {
+		Category []capsCategory `xml:"category"`
+	} `xml:"categories"`
+}
+
+type capsMode struct {
+	XMLName         xml.Name
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type capsCategory struct {
+	ID     int            `xml:"id,attr"`
+	Name   string         `xml:"name,attr"`
+	Subcat []capsCategory `xml:"subcat"`
+}
+
+func newCapsDocument(caps jackett.Caps) capsDocument {
+	var doc capsDocument
+	doc.Server.Title = caps.ServerTitle
+	doc.Limits.Default = caps.Limits.Default
+	doc.Limits.Max = caps.Limits.Max
+
+	modes := make([]string, 0, len(caps.Modes))
+	for mode := range caps.Modes {
+		modes = append(modes, mode)
+	}
+
+	sort.Strings(modes)
+
+	for _, mode := range modes {
+		doc.Searching.Modes = append(doc.Searching.Modes, capsMode{
+			XMLName:         xml.Name{Local: mode},
+			Available:       "yes",
+			SupportedParams: strings.Join(caps.Modes[mode].Params, ","),
+		})
+	}
+
+	doc.Categories.Category = capsCategories(caps.Categories)
+
+	return doc
+}
+
+func capsCategories(cats []jackett.CapsCategory) []capsCategory {
+	var out []capsCategory
+	for _, c := range cats {
+		out = append(out, capsCategory{ID: c.ID, Name: c.Name, Subcat: capsCategories(c.Subcats)})
+	}
+
+	return out
+}
+
+// requestURL returns the url of the request without the api key, for the
+// self link of feeds.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&b).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status, code int, description string) {
+	b, _ := xml.Marshal(struct {
+		XMLName     xml.Name `xml:"error"`
+		Code        int      `xml:"code,attr"`
+		Description string   `xml:"description,attr"`
+	}{Code: code, Description: description})
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
@@ -0,0 +1,273 @@
+package torznabserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jackett "github.com/kylesanderson/go-jackett"
+	"github.com/kylesanderson/go-jackett/jackettest"
+	"github.com/kylesanderson/go-jackett/torznabserver"
+)
+
+// mergeItem returns an item published hours after the start of 2024.
+func mergeItem(guid, hash string, seeders, hours int) jackett.FeedItem {
+	item := jackett.FeedItem{
+		Title:   guid,
+		Guid:    guid,
+		Link:    "https://tracker.invalid/" + guid,
+		PubDate: time.Date(2024, 1, 1, hours, 0, 0, 0, time.UTC).Format(time.RFC1123Z),
+		Attr:    []jackett.ItemAttr{{Name: string(jackett.AttrSeeders), Value: strconv.Itoa(seeders)}},
+	}
+
+	if hash != "" {
+		item.Attr = append(item.Attr, jackett.ItemAttr{Name: string(jackett.AttrInfoHash), Value: hash})
+	}
+
+	return item
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		results [][]jackett.FeedItem
+		want    []string
+	}{
+		{
+			name:    "newest first",
+			results: [][]jackett.FeedItem{{mergeItem("a", "", 1, 1)}, {mergeItem("b", "", 1, 3), mergeItem("c", "", 1, 2)}},
+			want:    []string{"b", "c", "a"},
+		},
+		{
+			name:    "same guid",
+			results: [][]jackett.FeedItem{{mergeItem("a", "", 1, 1)}, {mergeItem("a", "", 1, 1)}},
+			want:    []string{"a"},
+		},
+		{
+			name:    "same hash",
+			results: [][]jackett.FeedItem{{mergeItem("a", "ABCDEF", 1, 1)}, {mergeItem("b", "abcdef", 9, 1)}},
+			want:    []string{"b"},
+		},
+		{
+			name:    "keeps the most seeded",
+			results: [][]jackett.FeedItem{{mergeItem("a", "abcdef", 9, 1)}, {mergeItem("b", "abcdef", 1, 1)}},
+			want:    []string{"a"},
+		},
+		{
+			name:    "same guid in one result",
+			results: [][]jackett.FeedItem{{mergeItem("a", "", 1, 1), mergeItem("a", "", 1, 1)}},
+			want:    []string{"a"},
+		},
+		{
+			name: "none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles []string
+			for _, item := range torznabserver.Merge(tt.results...) {
+				titles = append(titles, item.Title)
+			}
+
+			assert.Equal(t, tt.want, titles)
+		})
+	}
+}
+
+func TestMergeCaps(t *testing.T) {
+	first := jackett.Caps{
+		Limits: jackett.CapsLimits{Default: 50, Max: 100},
+		Modes: map[string]jackett.SearchModeCaps{
+			jackett.SearchModeTV: {Params: []string{"q", "season", "ep"}},
+		},
+		Categories: []jackett.CapsCategory{
+			{ID: 5000, Name: "TV", Subcats: []jackett.CapsCategory{{ID: 5040, Name: "TV/HD"}}},
+		},
+	}
+
+	second := jackett.Caps{
+		Limits: jackett.CapsLimits{Default: 100, Max: 75},
+		Modes: map[string]jackett.SearchModeCaps{
+			jackett.SearchModeTV:    {Params: []string{"q", "imdbid"}},
+			jackett.SearchModeMovie: {Params: []string{"q"}},
+		},
+		Categories: []jackett.CapsCategory{
+			{ID: 5000, Name: "TV", Subcats: []jackett.CapsCategory{{ID: 5040, Name: "TV/HD"}, {ID: 5030, Name: "TV/SD"}}},
+			{ID: 2000, Name: "Movies"},
+		},
+	}
+
+	merged := torznabserver.MergeCaps(first, second)
+
+	assert.Equal(t, jackett.CapsLimits{Default: 100, Max: 100}, merged.Limits)
+	assert.Equal(t, []string{"q", "season", "ep", "imdbid"}, merged.Modes[jackett.SearchModeTV].Params)
+	assert.Equal(t, []string{"q"}, merged.Modes[jackett.SearchModeMovie].Params)
+	assert.Equal(t, []int{5000, 5040, 5030, 2000}, merged.CategoryIDs())
+}
+
+// newBackends starts two fake Jacketts with a torrent in common, the second
+// one seeding it better.
+func newBackends(t *testing.T) []*jackettest.Server {
+	first := jackettest.NewServer("apikey")
+	t.Cleanup(first.Close)
+
+	second := jackettest.NewServer("apikey")
+	t.Cleanup(second.Close)
+
+	first.AddIndexer(jackettest.NewIndexer("movies", "Movies", 2000),
+		jackettest.Item("Movie 2024 1080p", 2000, 5),
+		jackettest.Item("Movie 2024 720p", 2000, 3))
+	second.AddIndexer(jackettest.NewIndexer("tv", "TV", 5000),
+		jackettest.Item("Movie 2024 1080p", 2000, 50),
+		jackettest.Item("Show S01E01 1080p", 5000, 8))
+
+	return []*jackettest.Server{first, second}
+}
+
+// serve starts the handler at /api and returns a client of it.
+func serve(t *testing.T, h http.Handler) *jackett.Client {
+	mux := http.NewServeMux()
+	mux.Handle("/api", h)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return jackett.NewClient(jackett.Config{Host: srv.URL, APIKey: "serverkey", DirectMode: true})
+}
+
+func TestHandlerSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   map[string]int
+	}{
+		{
+			name:   "merged",
+			params: map[string]string{"t": "search", "q": "1080p"},
+			want:   map[string]int{"Movie 2024 1080p": 50, "Show S01E01 1080p": 8},
+		},
+		{
+			name:   "every backend",
+			params: map[string]string{"t": "search"},
+			want:   map[string]int{"Movie 2024 1080p": 50, "Movie 2024 720p": 3, "Show S01E01 1080p": 8},
+		},
+		{
+			name:   "limit",
+			params: map[string]string{"t": "search", "q": "movie", "limit": "1"},
+			want:   map[string]int{"Movie 2024 1080p": 50},
+		},
+		{
+			name:   "none",
+			params: map[string]string{"t": "tvsearch", "q": "nothing"},
+			want:   map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var backends []torznabserver.Backend
+			for _, srv := range newBackends(t) {
+				backends = append(backends, torznabserver.Backend{Client: srv.Client()})
+			}
+
+			client := serve(t, torznabserver.New(torznabserver.Config{Backends: backends, APIKeys: []string{"serverkey"}}))
+
+			rss, err := client.GetTorrentsCtx(context.Background(), "all", tt.params)
+			require.NoError(t, err)
+
+			got := make(map[string]int)
+			for _, item := range rss.Channel.Item {
+				got[item.Title] = item.Seeders()
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandlerBackendFailure(t *testing.T) {
+	servers := newBackends(t)
+	servers[1].Fail("tv", jackettest.Failure{Code: jackett.ErrCodeIncorrectCredentials, Description: "Login failed"})
+
+	backends := []torznabserver.Backend{
+		{Client: servers[0].Client()},
+		{Client: servers[1].Client(), Indexer: "tv"},
+	}
+
+	client := serve(t, torznabserver.New(torznabserver.Config{Backends: backends, APIKeys: []string{"serverkey"}}))
+
+	// the failing backend is left out
+	rss, err := client.GetTorrentsCtx(context.Background(), "all", map[string]string{"t": "search"})
+	require.NoError(t, err)
+	assert.Len(t, rss.Channel.Item, 2)
+
+	// the search fails when every backend does
+	client = serve(t, torznabserver.New(torznabserver.Config{Backends: backends[1:], APIKeys: []string{"serverkey"}}))
+
+	_, err = client.GetTorrentsCtx(context.Background(), "all", map[string]string{"t": "search"})
+	require.Error(t, err)
+}
+
+func TestHandlerCaps(t *testing.T) {
+	servers := newBackends(t)
+
+	backends := []torznabserver.Backend{
+		{Client: servers[0].Client(), Indexer: "movies"},
+		{Client: servers[1].Client(), Indexer: "tv"},
+	}
+
+	client := serve(t, torznabserver.New(torznabserver.Config{Backends: backends, Title: "merged"}))
+
+	caps, err := client.GetCapsForIndexerCtx(context.Background(), "all")
+	require.NoError(t, err)
+
+	assert.Equal(t, "merged", caps.ServerTitle)
+	assert.ElementsMatch(t, []int{2000, 5000}, caps.CategoryIDs())
+	assert.True(t, caps.SupportsMode(jackett.SearchModeTV))
+	assert.False(t, caps.SupportsMode(jackett.SearchModeMusic))
+}
+
+func TestHandlerRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		status int
+		code   int
+	}{
+		{name: "missing key", query: "t=caps", status: http.StatusUnauthorized, code: jackett.ErrCodeIncorrectCredentials},
+		{name: "wrong key", query: "t=caps&apikey=wrong", status: http.StatusUnauthorized, code: jackett.ErrCodeIncorrectCredentials},
+		{name: "key param", query: "t=caps&apikey=serverkey", status: http.StatusOK},
+		{name: "key header", query: "t=caps", header: "serverkey", status: http.StatusOK},
+		{name: "missing function", query: "apikey=serverkey", status: http.StatusOK, code: jackett.ErrCodeMissingParameter},
+		{name: "unknown function", query: "t=details&apikey=serverkey", status: http.StatusOK, code: jackett.ErrCodeNoSuchFunction},
+	}
+
+	h := torznabserver.New(torznabserver.Config{APIKeys: []string{"serverkey"}})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api?"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Api-Key", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.code != 0 {
+				assert.Contains(t, rec.Body.String(), `<error code="`+strconv.Itoa(tt.code)+`"`)
+			} else {
+				assert.NotContains(t, rec.Body.String(), "<error")
+			}
+		})
+	}
+}
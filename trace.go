@@ -0,0 +1,136 @@
+package jackett
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Tracer starts a span for every request of a client: searches, caps and
+// indexer listings, and enclosure downloads. It is small enough to adapt an
+// OpenTelemetry tracer, e.g. by wrapping provider.Tracer("go-jackett").
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced request. Attributes set on it include jackett.indexer,
+// jackett.search_type, http.status_code and duration_ms.
+type Span interface {
+	SetAttribute(key string, value interface{})
+
+	// End ends the span, err is nil when the request succeeded
+	End(err error)
+}
+
+type spanKey struct{}
+
+// span instruments a request for the Tracer and MetricsRecorder of a client
+// and is a no-op without either.
+type span struct {
+	Span
+
+	metrics  MetricsRecorder
+	clock    Clock
+	start    time.Time
+	indexer  string
+	endpoint string
+	status   int
+}
+
+// startSpan starts a span named after the endpoint, e.g. jackett.search, and
+// stores it in the returned context so retryDo can record the status code.
+func (c *Client) startSpan(ctx context.Context, endpoint, indexer string, attrs ...interface{}) (context.Context, *span) {
+	if c.cfg.Tracer == nil && c.cfg.Metrics == nil {
+		return ctx, nil
+	}
+
+	sp := &span{
+		metrics:  c.cfg.Metrics,
+		clock:    c.clock,
+		indexer:  indexer,
+		endpoint: endpoint,
+	}
+
+	if c.cfg.Tracer != nil {
+		ctx, sp.Span = c.cfg.Tracer.Start(ctx, "jackett."+endpoint)
+
+		sp.SetAttribute("jackett.indexer", indexer)
+		for i := 0; i+1 < len(attrs); i += 2 {
+			if key, ok := attrs[i].(string); ok {
+				sp.SetAttribute(key, attrs[i+1])
+			}
+		}
+	}
+
+	sp.start = c.clock.Now()
+
+	return context.WithValue(ctx, spanKey{}, sp), sp
+}
+
+func spanFromContext(ctx context.Context) *span {
+	sp, _ := ctx.Value(spanKey{}).(*span)
+	return sp
+}
+
+func (s *span) setStatus(status int) {
+	if s == nil {
+		return
+	}
+
+	s.status = status
+	if s.Span != nil {
+		s.SetAttribute("http.status_code", status)
+	}
+}
+
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+
+	elapsed := s.clock.Now().Sub(s.start)
+
+	if s.metrics != nil {
+		s.metrics.ObserveRequest(RequestMetric{
+			Indexer:  s.indexer,
+			Endpoint: s.endpoint,
+			Status:   s.status,
+			Duration: elapsed,
+			Err:      err,
+		})
+	}
+
+	if s.Span != nil {
+		s.SetAttribute("duration_ms", elapsed.Milliseconds())
+		s.End(err)
+	}
+}
+
+// torznabEndpoint names the endpoint of a torznab request by its t param.
+func torznabEndpoint(t string) string {
+	switch t {
+	case "caps", "indexers":
+		return t
+	}
+
+	return "search"
+}
+
+// enclosureIndexer returns the indexer of a Jackett download link, which
+// looks like /dl/{indexer}/?jackett_apikey=...
+func enclosureIndexer(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "dl" {
+			return parts[i+1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,98 @@
+package jackett
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var ErrUnsupportedSearch = errors.Sentinel("unsupported search")
+
+// searchParams are the torznab params a search mode has to list in its
+// supported params to honor them. Others, e.g. offset or extended, are
+// accepted by every mode.
+var searchParams = append([]string{
+	"q", "season", "ep", "year", "genre",
+	"artist", "album", "label", "track",
+	"author", "title", "publisher",
+}, idParams...)
+
+// genericParams are accepted by every search mode.
+var genericParams = map[string]bool{
+	"t": true, "apikey": true, "cat": true, "limit": true, "offset": true,
+	"extended": true, "attrs": true,
+}
+
+// ValidateSearch checks the search type, params and categories of opts
+// against the caps of the target indexer, since trackers tend to ignore what
+// they don't support and return unrelated or empty results instead.
+func (c *Client) ValidateSearch(caps Caps, opts map[string]string) error {
+	return validateSearch(caps, opts)
+}
+
+func validateSearch(caps Caps, opts map[string]string) error {
+	t := opts["t"]
+	if t == "" {
+		t = "search"
+	}
+
+	mode, ok := searchModes[t]
+	if !ok {
+		return errors.Wrap(ErrUnsupportedSearch, "unknown search type %q", t)
+	}
+
+	if !caps.SupportsMode(mode) {
+		return errors.Wrap(ErrUnsupportedSearch, "search type %q not available, available: %v", t, strings.Join(supportedModes(caps), ", "))
+	}
+
+	var problems []string
+	for _, p := range searchParams {
+		if opts[p] == "" || caps.Supports(mode, p) {
+			continue
+		}
+
+		// plain text searches always take a query
+		if p == "q" && mode == SearchModeSearch {
+			continue
+		}
+
+		problems = append(problems, "param "+p)
+	}
+
+	if ids := caps.CategoryIDs(); len(ids) > 0 {
+		known := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			known[id] = true
+		}
+
+		for _, cat := range parseCategories(opts["cat"]) {
+			if !known[cat] {
+				problems = append(problems, "category "+strconv.Itoa(cat))
+			}
+		}
+	}
+
+	if limit, err := strconv.Atoi(opts["limit"]); err == nil && caps.Limits.Max > 0 && limit > caps.Limits.Max {
+		problems = append(problems, "limit "+opts["limit"]+" above "+strconv.Itoa(caps.Limits.Max))
+	}
+
+	if len(problems) > 0 {
+		return errors.Wrap(ErrUnsupportedSearch, "%v does not support %v", t, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+func supportedModes(caps Caps) []string {
+	var modes []string
+	for t, mode := range searchModes {
+		if caps.SupportsMode(mode) {
+			modes = append(modes, t)
+		}
+	}
+
+	sort.Strings(modes)
+	return modes
+}
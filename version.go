@@ -0,0 +1,27 @@
+package jackett
+
+import "github.com/kylesanderson/go-jackett/internal/errors"
+
+// ErrUnsupportedVersion is returned for persisted data written in a format
+// version this package doesn't know, e.g. by a newer release.
+var ErrUnsupportedVersion = errors.Sentinel("unsupported format version")
+
+// Versions of the persisted formats, bumped on incompatible changes. Older
+// versions are migrated when read, newer ones fail with
+// ErrUnsupportedVersion.
+const (
+	// files written by SaveCache
+	CacheFormatVersion = 1
+
+	// tables of SQLStore
+	SQLSchemaVersion = 1
+)
+
+// checkVersion fails for versions of the format other than 1 up to current.
+func checkVersion(format string, version, current int) error {
+	if version < 1 || version > current {
+		return errors.Wrap(ErrUnsupportedVersion, "%v version %d, supported up to %d", format, version, current)
+	}
+
+	return nil
+}
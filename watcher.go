@@ -0,0 +1,515 @@
+package jackett
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kylesanderson/go-jackett/internal/errors"
+)
+
+var (
+	DefaultWatchInterval = 15 * time.Minute
+
+	DefaultWatchWorkers = 4
+)
+
+// DeliveryPolicy decides what happens to new events when the consumer does
+// not keep up and the event buffer is full.
+type DeliveryPolicy int
+
+const (
+	// DeliveryBlock waits for the consumer, pausing polling.
+	DeliveryBlock DeliveryPolicy = iota
+
+	// DeliveryDropOldest discards the oldest buffered event.
+	DeliveryDropOldest
+
+	// DeliveryDropNewest discards the event being delivered.
+	DeliveryDropNewest
+)
+
+type WatchFeed struct {
+	// name reported on events, defaults to the indexer
+	Name string
+
+	Client  *Client
+	Indexer string
+	Params  map[string]string
+
+	// poll interval, defaults to WatcherConfig.Interval
+	Interval time.Duration
+}
+
+type WatcherConfig struct {
+	Feeds []WatchFeed
+
+	// default poll interval, defaults to DefaultWatchInterval
+	Interval time.Duration
+
+	// number of feeds polled at once, defaults to DefaultWatchWorkers
+	Workers int
+
+	Delivery DeliveryPolicy
+
+	// number of events buffered for the consumer
+	BufferSize int
+
+	// clock used for poll timing, defaults to the system clock
+	Clock Clock
+
+	// new items and errors are written to Events when set
+	Events *EventWriter
+
+	// remembers delivered items across restarts when set
+	Seen SeenStore
+
+	// items blocked here are never delivered
+	Blocklist Blocklist
+
+	// persists the feed watermarks when set, so restarts only deliver new items
+	Watermarks WatermarkStore
+
+	// evaluated for every new item when set, before it is delivered
+	Rules *RuleEngine
+
+	// notified of new items and errors when set
+	Notifier Notifier
+
+	// suppresses different releases of the same episode when set
+	Dedup *EpisodeDedup
+
+	// items whose content is owned are skipped when set
+	Library Library
+}
+
+type WatchEvent struct {
+	Feed string
+	Item FeedItem
+	Err  error
+
+	// names of the rules that matched the item
+	Rules []string
+}
+
+// FeedSchedule reports when a feed was and will be polled.
+type FeedSchedule struct {
+	Feed    string
+	LastRun time.Time
+	NextRun time.Time
+	Running bool
+}
+
+// Watcher polls feeds and delivers items it has not seen before. Feeds are
+// polled by a bounded pool of workers, the most overdue feed first.
+type Watcher struct {
+	cfg    WatcherConfig
+	events chan WatchEvent
+
+	mu    sync.Mutex
+	feeds []*feedState
+
+	dedup *dedupState
+
+	// serializes drop-oldest delivery
+	sendMu sync.Mutex
+
+	dropped uint64
+}
+
+func NewWatcher(cfg WatcherConfig) *Watcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultWatchInterval
+	}
+
+	if cfg.BufferSize < 0 {
+		cfg.BufferSize = 0
+	}
+
+	if cfg.Delivery == DeliveryDropOldest && cfg.BufferSize == 0 {
+		cfg.BufferSize = 1
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWatchWorkers
+	}
+
+	for i := range cfg.Feeds {
+		if cfg.Feeds[i].Name == "" {
+			cfg.Feeds[i].Name = cfg.Feeds[i].Indexer
+		}
+
+		if cfg.Feeds[i].Interval <= 0 {
+			cfg.Feeds[i].Interval = cfg.Interval
+		}
+	}
+
+	w := &Watcher{
+		cfg:    cfg,
+		events: make(chan WatchEvent, cfg.BufferSize),
+	}
+
+	if cfg.Dedup != nil {
+		w.dedup = newDedupState(*cfg.Dedup)
+	}
+
+	now := cfg.Clock.Now()
+	for _, feed := range cfg.Feeds {
+		w.feeds = append(w.feeds, &feedState{feed: feed, next: now})
+	}
+
+	return w
+}
+
+type feedState struct {
+	feed WatchFeed
+
+	// only touched by the worker polling the feed
+	mark   Watermark
+	loaded bool
+
+	last    time.Time
+	next    time.Time
+	running bool
+}
+
+// Schedule returns the last and next poll of every feed.
+func (w *Watcher) Schedule() []FeedSchedule {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]FeedSchedule, 0, len(w.feeds))
+	for _, fs := range w.feeds {
+		out = append(out, FeedSchedule{
+			Feed:    fs.feed.Name,
+			LastRun: fs.last,
+			NextRun: fs.next,
+			Running: fs.running,
+		})
+	}
+
+	return out
+}
+
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Dropped returns the number of events discarded by the delivery policy.
+func (w *Watcher) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Run polls all feeds until ctx is done. The events channel is closed when
+// Run returns.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	jobs := make(chan *feedState)
+
+	// buffered so workers never block on a scheduler that stopped
+	done := make(chan *feedState, w.cfg.Workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for fs := range jobs {
+				w.poll(ctx, fs)
+				done <- fs
+			}
+		}()
+	}
+
+	defer wg.Wait()
+	defer close(jobs)
+
+	idle := w.cfg.Workers
+	for {
+		w.releaseHeld(ctx)
+
+		for idle > 0 {
+			fs := w.due(w.cfg.Clock.Now())
+			if fs == nil {
+				break
+			}
+
+			idle--
+			jobs <- fs
+		}
+
+		next, ok := w.nextRun()
+		ok = ok && idle > 0
+
+		if w.dedup != nil {
+			if held, heldOk := w.dedup.next(); heldOk && (!ok || held.Before(next)) {
+				next, ok = held, true
+			}
+		}
+
+		var wake <-chan time.Time
+		if ok {
+			wake = w.cfg.Clock.After(next.Sub(w.cfg.Clock.Now()))
+		}
+
+		select {
+		case fs := <-done:
+			idle++
+
+			w.mu.Lock()
+			fs.running = false
+			fs.next = w.cfg.Clock.Now().Add(fs.feed.Interval)
+			w.mu.Unlock()
+
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// due marks the most overdue feed as running and returns it, or nil when no
+// feed is due.
+func (w *Watcher) due(now time.Time) *feedState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var next *feedState
+	for _, fs := range w.feeds {
+		if fs.running || fs.next.After(now) {
+			continue
+		}
+
+		if next == nil || fs.next.Before(next.next) {
+			next = fs
+		}
+	}
+
+	if next != nil {
+		next.running = true
+		next.last = now
+	}
+
+	return next
+}
+
+// nextRun returns the earliest next run of the feeds not being polled.
+func (w *Watcher) nextRun() (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var (
+		next time.Time
+		ok   bool
+	)
+
+	for _, fs := range w.feeds {
+		if fs.running {
+			continue
+		}
+
+		if !ok || fs.next.Before(next) {
+			next, ok = fs.next, true
+		}
+	}
+
+	return next, ok
+}
+
+// poll delivers the items above the watermark of the feed and raises it.
+func (w *Watcher) poll(ctx context.Context, fs *feedState) {
+	feed := fs.feed
+
+	if !fs.loaded && w.cfg.Watermarks != nil {
+		mark, ok, err := w.cfg.Watermarks.Watermark(feed.Name)
+		if err != nil {
+			err = errors.Wrap(err, "watermark store error")
+			w.reportError(ctx, feed.Name, err)
+			return
+		}
+
+		if ok {
+			fs.mark = mark
+		}
+	}
+
+	fs.loaded = true
+
+	rss, err := feed.Client.GetTorrentsCtx(ctx, feed.Indexer, feed.Params)
+	if err != nil {
+		// the client reports backend outages through its callbacks once
+		if ctx.Err() == nil && !errors.Is(err, ErrBackendDown) {
+			w.reportError(ctx, feed.Name, err)
+		}
+
+		return
+	}
+
+	items := rss.Channel.Item
+	mark := fs.mark
+	changed := false
+
+	// feeds list newest first, deliver in release order
+	for i := len(items) - 1; i >= 0; i-- {
+		if !fs.mark.IsNew(items[i]) {
+			continue
+		}
+
+		mark.Add(items[i])
+		changed = true
+
+		if skip, err := w.stored(feed.Name, itemKey(items[i])); err != nil {
+			w.reportError(ctx, feed.Name, err)
+		} else if skip {
+			continue
+		}
+
+		if owned(w.cfg.Library, items[i]) {
+			continue
+		}
+
+		if w.dedup != nil && !w.dedup.offer(feed.Name, items[i], w.cfg.Clock.Now()) {
+			continue
+		}
+
+		w.deliverItem(ctx, feed.Name, items[i])
+	}
+
+	fs.mark = mark
+
+	if changed && w.cfg.Watermarks != nil {
+		if err := w.cfg.Watermarks.SetWatermark(feed.Name, mark); err != nil {
+			w.cfg.Events.Error(feed.Name, errors.Wrap(err, "watermark store error"))
+		}
+	}
+}
+
+// deliverItem runs the rules on a new item and delivers it.
+func (w *Watcher) deliverItem(ctx context.Context, feed string, item FeedItem) {
+	w.cfg.Events.Match(feed, item)
+	w.notify(ctx, Notification{Kind: EventMatch, Source: feed, Item: item})
+
+	ev := WatchEvent{Feed: feed, Item: item}
+
+	var ruleErr error
+	if w.cfg.Rules != nil {
+		ev.Rules, ruleErr = w.cfg.Rules.Evaluate(ctx, feed, item)
+	}
+
+	w.deliver(ctx, ev)
+
+	// failed actions are reported after the item they failed for
+	if ruleErr != nil {
+		w.reportError(ctx, feed, ruleErr)
+	}
+
+	if w.cfg.Seen != nil {
+		if err := w.cfg.Seen.MarkSeen(feed, itemKey(item)); err != nil {
+			w.cfg.Events.Error(feed, err)
+		}
+	}
+}
+
+// releaseHeld delivers the releases held by the dedup whose delay passed.
+func (w *Watcher) releaseHeld(ctx context.Context) {
+	if w.dedup == nil {
+		return
+	}
+
+	for _, h := range w.dedup.due(w.cfg.Clock.Now()) {
+		w.deliverItem(ctx, h.feed, h.item)
+	}
+}
+
+// reportError writes, notifies and delivers err.
+func (w *Watcher) reportError(ctx context.Context, feed string, err error) {
+	w.cfg.Events.Error(feed, err)
+	w.notify(ctx, Notification{Kind: EventError, Source: feed, Err: err})
+	w.deliver(ctx, WatchEvent{Feed: feed, Err: err})
+}
+
+func (w *Watcher) notify(ctx context.Context, n Notification) {
+	if w.cfg.Notifier == nil {
+		return
+	}
+
+	if err := w.cfg.Notifier.Notify(ctx, n); err != nil {
+		w.cfg.Events.Error(n.Source, errors.Wrap(err, "notification failed"))
+	}
+}
+
+// stored reports whether the item was delivered before a restart or is
+// blocked. Items are delivered when the stores fail.
+func (w *Watcher) stored(feed, key string) (bool, error) {
+	if w.cfg.Blocklist != nil {
+		blocked, err := w.cfg.Blocklist.Blocked(key)
+		if err != nil {
+			return false, errors.Wrap(err, "blocklist error")
+		} else if blocked {
+			return true, nil
+		}
+	}
+
+	if w.cfg.Seen != nil {
+		seen, err := w.cfg.Seen.Seen(feed, key)
+		if err != nil {
+			return false, errors.Wrap(err, "seen store error")
+		}
+
+		return seen, nil
+	}
+
+	return false, nil
+}
+
+func (w *Watcher) deliver(ctx context.Context, ev WatchEvent) {
+	switch w.cfg.Delivery {
+	case DeliveryDropNewest:
+		select {
+		case w.events <- ev:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+
+	case DeliveryDropOldest:
+		w.sendMu.Lock()
+		defer w.sendMu.Unlock()
+
+		for {
+			select {
+			case w.events <- ev:
+				return
+			default:
+			}
+
+			select {
+			case <-w.events:
+				atomic.AddUint64(&w.dropped, 1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case w.events <- ev:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// itemKey identifies an item across polls, falling back to the link for
+// indexers without guids.
+func itemKey(item FeedItem) string {
+	if item.Guid != "" {
+		return item.Guid
+	}
+
+	return item.Link
+}
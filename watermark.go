@@ -0,0 +1,49 @@
+package jackett
+
+import (
+	"time"
+)
+
+// WatermarkWindow is the number of recent item keys a watermark remembers.
+var WatermarkWindow = 200
+
+// Watermark tracks the newest publish date and the most recent items of a
+// feed, so only items newer than the watermark are delivered.
+type Watermark struct {
+	PubDate time.Time
+	Keys    []string
+}
+
+// WatermarkStore persists the watermarks of a watcher, so a restarted
+// watcher only delivers items published while it was down.
+type WatermarkStore interface {
+	Watermark(feed string) (Watermark, bool, error)
+	SetWatermark(feed string, mark Watermark) error
+}
+
+// IsNew reports whether the item is neither one of the recent items nor
+// published before the watermark. Items without a publish date are only
+// compared by key.
+func (m Watermark) IsNew(item FeedItem) bool {
+	key := itemKey(item)
+	for _, k := range m.Keys {
+		if k == key {
+			return false
+		}
+	}
+
+	published := item.PublishDate()
+	return published.IsZero() || m.PubDate.IsZero() || !published.Before(m.PubDate)
+}
+
+// Add raises the watermark to include item.
+func (m *Watermark) Add(item FeedItem) {
+	m.Keys = append(m.Keys, itemKey(item))
+	if n := len(m.Keys) - WatermarkWindow; n > 0 {
+		m.Keys = append([]string(nil), m.Keys[n:]...)
+	}
+
+	if published := item.PublishDate(); published.After(m.PubDate) {
+		m.PubDate = published
+	}
+}
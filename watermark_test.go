@@ -0,0 +1,104 @@
+package jackett_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	jackett "github.com/kylesanderson/go-jackett"
+)
+
+// datedItem returns an item published at the given time, or without a
+// publish date when it is zero.
+func datedItem(guid string, published time.Time) jackett.FeedItem {
+	item := jackett.FeedItem{Guid: guid}
+	if !published.IsZero() {
+		item.PubDate = published.Format(time.RFC1123Z)
+	}
+
+	return item
+}
+
+func TestWatermarkIsNew(t *testing.T) {
+	mark := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		mark jackett.Watermark
+		item jackett.FeedItem
+		want bool
+	}{
+		{
+			name: "empty watermark",
+			item: datedItem("a", mark),
+			want: true,
+		},
+		{
+			name: "newer",
+			mark: jackett.Watermark{PubDate: mark},
+			item: datedItem("a", mark.Add(time.Minute)),
+			want: true,
+		},
+		{
+			name: "same date",
+			mark: jackett.Watermark{PubDate: mark},
+			item: datedItem("a", mark),
+			want: true,
+		},
+		{
+			name: "older",
+			mark: jackett.Watermark{PubDate: mark},
+			item: datedItem("a", mark.Add(-time.Minute)),
+		},
+		{
+			name: "seen",
+			mark: jackett.Watermark{PubDate: mark, Keys: []string{"a"}},
+			item: datedItem("a", mark.Add(time.Minute)),
+		},
+		{
+			name: "undated",
+			mark: jackett.Watermark{PubDate: mark},
+			item: datedItem("a", time.Time{}),
+			want: true,
+		},
+		{
+			name: "undated seen",
+			mark: jackett.Watermark{PubDate: mark, Keys: []string{"a"}},
+			item: datedItem("a", time.Time{}),
+		},
+		{
+			name: "keyed by link without guid",
+			mark: jackett.Watermark{Keys: []string{"https://tracker.invalid/1"}},
+			item: jackett.FeedItem{Link: "https://tracker.invalid/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.mark.IsNew(tt.item))
+		})
+	}
+}
+
+func TestWatermarkAdd(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var mark jackett.Watermark
+	mark.Add(datedItem("a", start))
+	mark.Add(datedItem("b", start.Add(-time.Hour)))
+	mark.Add(datedItem("c", time.Time{}))
+
+	assert.True(t, start.Equal(mark.PubDate))
+	assert.Equal(t, []string{"a", "b", "c"}, mark.Keys)
+
+	// only the most recent keys are remembered
+	for n := 0; n < jackett.WatermarkWindow; n++ {
+		mark.Add(datedItem(fmt.Sprint(n), time.Time{}))
+	}
+
+	assert.Len(t, mark.Keys, jackett.WatermarkWindow)
+	assert.Equal(t, "0", mark.Keys[0])
+	assert.True(t, mark.IsNew(datedItem("a", start)))
+}